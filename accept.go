@@ -0,0 +1,21 @@
+package go_http_client
+
+import (
+	"net/http"
+)
+
+// WithDefaultAcceptOpt set the Accept header to mediaType unless an earlier
+// option set one, pass it after the other options to default the header to
+// the media type of the parser, e.g.
+//
+//	c.DoRequest(ctx, http.MethodGet, "/users", JsonParser(&users), WithDefaultAcceptOpt(MediaTypeJSON))
+//
+// The JSON and XML helpers such as GetJson and DoRequestXml add it themselves
+func WithDefaultAcceptOpt(mediaType string) RequestOption {
+	return func(req *http.Request) (e error) {
+		if req.Header.Get("Accept") == "" {
+			req.Header.Set("Accept", mediaType)
+		}
+		return
+	}
+}
@@ -0,0 +1,282 @@
+package go_http_client
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// DefaultArchiveFileBytes is the size at which an archive file is rotated
+// unless ArchiveConfig.MaxFileBytes is set
+const DefaultArchiveFileBytes = 10 << 20
+
+const archiveFile = "archive.jsonl"
+
+// DefaultArchiveBodyBytes is the amount of a body archived unless set in
+// ArchiveConfig
+const DefaultArchiveBodyBytes = 1 << 20
+
+// archiveNotCaptured replaces the bodies of streaming responses, which aren't
+// read ahead of the caller, and of streamed uploads, and archiveWithheld the cut JSON bodies which
+// can't be redacted
+const (
+	archiveNotCaptured = "[not captured]"
+	archiveWithheld    = "[withheld]"
+)
+
+// ArchiveConfig sets where and how much traffic an Archiver keeps
+type ArchiveConfig struct {
+	Dir string
+	// MaxFileBytes rotate the current file once it reaches this size,
+	// DefaultArchiveFileBytes when 0
+	MaxFileBytes int64
+	// MaxFiles is the number of rotated files kept, the oldest are removed,
+	// 0 keeps them all
+	MaxFiles int
+	// MaxBodyBytes cut each archived body, DefaultArchiveBodyBytes when not
+	// positive. Only that part of a response is read before the caller gets it
+	MaxBodyBytes int64
+}
+
+// Archiver persists request/response pairs to a directory, one JSON object
+// per line in archive.jsonl, rotated to archive-<time>.jsonl as it grows.
+// Headers, query parameters and JSON bodies are redacted as in the debug
+// dumps, JSON bodies cut at MaxBodyBytes are withheld and the bodies of
+// streaming responses such as text/event-stream and of streamed uploads
+// aren't archived. An Archiver can be shared by several clients, the first
+// to Shutdown closes it
+type Archiver struct {
+	cfg  ArchiveConfig
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// NewArchiver create the directory if needed and open the current archive file
+func NewArchiver(cfg ArchiveConfig) (*Archiver, error) {
+	if cfg.Dir == "" {
+		return nil, fmt.Errorf("NewArchiver error: %v", cfg)
+	}
+	if cfg.MaxFileBytes == 0 {
+		cfg.MaxFileBytes = DefaultArchiveFileBytes
+	}
+	if cfg.MaxBodyBytes <= 0 {
+		cfg.MaxBodyBytes = DefaultArchiveBodyBytes
+	}
+	if err := os.MkdirAll(cfg.Dir, 0o750); err != nil {
+		return nil, err
+	}
+
+	a := &Archiver{cfg: cfg}
+	if err := a.open(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// WithArchive archive the exchanges whose URL path starts with pathPrefix to
+// a, the longest matching prefix wins so paths can be routed to archivers
+// with their own directory and limits. An empty prefix matches every path.
+// Only exchanges which got a response are archived
+func WithArchive(pathPrefix string, a *Archiver) Option {
+	return func(c *Client) {
+		if c.archives == nil {
+			c.archives = make(map[string]*Archiver)
+		}
+		c.archives[pathPrefix] = a
+	}
+}
+
+// archiverFor returns the archiver of the longest prefix of path, nil when
+// none matches
+func (c *Client) archiverFor(path string) *Archiver {
+	var found *Archiver
+	best := -1
+	for prefix, a := range c.archives {
+		if len(prefix) > best && strings.HasPrefix(path, prefix) {
+			found, best = a, len(prefix)
+		}
+	}
+	return found
+}
+
+// Flush commit the archived records to disk
+func (a *Archiver) Flush(ctx context.Context) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.file == nil {
+		return nil
+	}
+	return a.file.Sync()
+}
+
+// Close close the current archive file, Client.Shutdown closes the archivers
+// of the client
+func (a *Archiver) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.file == nil {
+		return nil
+	}
+	err := a.file.Close()
+	a.file = nil
+	return err
+}
+
+type archiveRecord struct {
+	Time       time.Time      `json:"time"`
+	Method     string         `json:"method"`
+	URL        string         `json:"url"`
+	Status     int            `json:"status"`
+	DurationMs float64        `json:"duration_ms"`
+	Tags       Tags           `json:"tags,omitempty"`
+	CostCenter string         `json:"cost_center,omitempty"`
+	Caller     string         `json:"caller,omitempty"`
+	Request    archiveMessage `json:"request"`
+	Response   archiveMessage `json:"response"`
+}
+
+type archiveMessage struct {
+	Header     http.Header `json:"header"`
+	Body       string      `json:"body,omitempty"`
+	BodyBase64 string      `json:"body_base64,omitempty"`
+	Truncated  bool        `json:"truncated,omitempty"`
+}
+
+// record archive the exchange, the response body is replaced by a reader of
+// the same content. Only a replayable request body can be archived since it
+// was sent already, streamed uploads aren't read again
+func (a *Archiver) record(req *http.Request, resp *http.Response, meta *Response, start time.Time, r *redactor) error {
+	respMessage := archiveMessage{Header: r.header(resp.Header), Body: archiveNotCaptured}
+	if !isStreamingMediaType(mediaType(resp.Header)) {
+		respBody, truncated, err := readBody(&resp.Body, a.cfg.MaxBodyBytes)
+		if err != nil {
+			return err
+		}
+		respMessage = a.message(resp.Header, respBody, truncated, r)
+	}
+
+	attribution := AttributionFromContext(req.Context())
+	rec := archiveRecord{
+		Time:       start,
+		Method:     req.Method,
+		URL:        r.url(req.URL).String(),
+		Status:     resp.StatusCode,
+		DurationMs: float64(meta.Duration) / float64(time.Millisecond),
+		Tags:       TagsFromContext(req.Context()),
+		CostCenter: attribution.CostCenter,
+		Caller:     attribution.Caller,
+		Request:    archiveMessage{Header: r.header(req.Header)},
+		Response:   respMessage,
+	}
+	if req.GetBody != nil && !isStreamedBody(req) && !isStreamingMediaType(mediaType(req.Header)) {
+		if body, err := req.GetBody(); err == nil {
+			reqBody, truncated, err := readBody(&body, a.cfg.MaxBodyBytes)
+			body.Close()
+			if err == nil {
+				rec.Request = a.message(req.Header, reqBody, truncated, r)
+			}
+		}
+	} else if hasBody(req) {
+		rec.Request.Body = archiveNotCaptured
+	}
+
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	return a.write(append(line, '\n'))
+}
+
+func (a *Archiver) message(h http.Header, body []byte, truncated bool, r *redactor) archiveMessage {
+	m := archiveMessage{Header: r.header(h), Truncated: truncated}
+	if len(r.jsonRules) > 0 && isJSONMediaType(mediaType(h)) {
+		if truncated {
+			// a cut document can't be parsed, hence redacted
+			m.Body = archiveWithheld
+			return m
+		}
+		body = r.redactJSONBody(body)
+	}
+	if utf8.Valid(body) {
+		m.Body = string(body)
+	} else {
+		m.BodyBase64 = base64.StdEncoding.EncodeToString(body)
+	}
+	return m
+}
+
+func (a *Archiver) write(line []byte) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.file == nil {
+		return fmt.Errorf("archiver of %v is closed", a.cfg.Dir)
+	}
+	if a.size > 0 && a.size+int64(len(line)) > a.cfg.MaxFileBytes {
+		if err := a.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := a.file.Write(line)
+	a.size += int64(n)
+	return err
+}
+
+func (a *Archiver) open() error {
+	f, err := os.OpenFile(filepath.Join(a.cfg.Dir, archiveFile), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	a.file, a.size = f, info.Size()
+	return nil
+}
+
+// rotate rename the current file after the time and prune the oldest files,
+// the caller holds the lock
+func (a *Archiver) rotate() error {
+	if err := a.file.Close(); err != nil {
+		return err
+	}
+	a.file = nil
+	rotated := fmt.Sprintf("archive-%v.jsonl", time.Now().UTC().Format("20060102T150405.000000000"))
+	if err := os.Rename(filepath.Join(a.cfg.Dir, archiveFile), filepath.Join(a.cfg.Dir, rotated)); err != nil {
+		return err
+	}
+	if err := a.open(); err != nil {
+		return err
+	}
+	return a.prune()
+}
+
+func (a *Archiver) prune() error {
+	if a.cfg.MaxFiles <= 0 {
+		return nil
+	}
+	files, err := filepath.Glob(filepath.Join(a.cfg.Dir, "archive-*.jsonl"))
+	if err != nil {
+		return err
+	}
+	// the time format sorts chronologically
+	sort.Strings(files)
+	for len(files) > a.cfg.MaxFiles {
+		if err := os.Remove(files[0]); err != nil {
+			return err
+		}
+		files = files[1:]
+	}
+	return nil
+}
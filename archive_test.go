@@ -0,0 +1,93 @@
+package go_http_client
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func readArchive(t *testing.T, dir string) []archiveRecord {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, archiveFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var records []archiveRecord
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var rec archiveRecord
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, rec)
+	}
+	return records
+}
+
+func TestArchiveSkipsStreamedUploads(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(ioutil.Discard, r.Body)
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	a, err := NewArchiver(ArchiveConfig{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	c := NewClient(srv.URL, WithArchive("", a))
+
+	opens := 0
+	b := NewMultipartBuilder().Stream("file", "data.bin", func() (io.ReadCloser, error) {
+		opens++
+		return ioutil.NopCloser(strings.NewReader("payload")), nil
+	}, 7)
+	var out string
+	if err := c.DoRequestString(context.Background(), http.MethodPost, "/upload", &out, WithMultipartStreamOpt(b)); err != nil {
+		t.Fatal(err)
+	}
+	if opens != 1 {
+		t.Fatalf("upload opened %v times, want 1", opens)
+	}
+	records := readArchive(t, dir)
+	if len(records) != 1 || records[0].Request.Body != archiveNotCaptured {
+		t.Fatalf("records = %+v", records)
+	}
+}
+
+func TestArchiveDefaultBodyLimit(t *testing.T) {
+	large := strings.Repeat("a", DefaultArchiveBodyBytes+10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(large))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	a, err := NewArchiver(ArchiveConfig{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	c := NewClient(srv.URL, WithArchive("", a))
+
+	var out string
+	if err := c.DoRequestString(context.Background(), http.MethodGet, "/large", &out); err != nil {
+		t.Fatal(err)
+	}
+	if out != large {
+		t.Fatalf("caller got %v bytes, want %v", len(out), len(large))
+	}
+	records := readArchive(t, dir)
+	if len(records) != 1 || !records[0].Response.Truncated || len(records[0].Response.Body) != DefaultArchiveBodyBytes {
+		t.Fatalf("response archived with %v bytes, truncated %v", len(records[0].Response.Body), records[0].Response.Truncated)
+	}
+}
@@ -0,0 +1,70 @@
+package go_http_client
+
+import (
+	"context"
+	"net/http"
+)
+
+// Attribution identifies who a request is made for, so third party API
+// usage going through shared clients can be attributed to internal products.
+// It is counted in Stats, recorded in archives and contract interactions and
+// added to the request tags as CostCenterTag and CallerTag, so it reaches the
+// log fields, the hooks and the metrics modules
+type Attribution struct {
+	CostCenter string
+	Caller     string
+}
+
+// CostCenterTag and CallerTag are the tags holding the attribution of a
+// request, see Tags
+const (
+	CostCenterTag = "cost_center"
+	CallerTag     = "caller"
+)
+
+type attributionKey struct{}
+
+// ContextWithAttribution attach the attribution to requests made with ctx
+func ContextWithAttribution(ctx context.Context, a Attribution) context.Context {
+	return context.WithValue(ctx, attributionKey{}, a)
+}
+
+// AttributionFromContext returns the attribution stored in ctx, if any
+func AttributionFromContext(ctx context.Context) Attribution {
+	a, _ := ctx.Value(attributionKey{}).(Attribution)
+	return a
+}
+
+// WithAttributionHeaders send the context attribution to the upstream in the
+// given headers, an empty header name disables sending that value
+func WithAttributionHeaders(costCenterHeader, callerHeader string) Option {
+	return func(c *Client) {
+		c.costCenterHeader = costCenterHeader
+		c.callerHeader = callerHeader
+	}
+}
+
+func (c *Client) setAttributionHeaders(req *http.Request) {
+	a := AttributionFromContext(req.Context())
+	if c.costCenterHeader != "" && a.CostCenter != "" {
+		req.Header.Set(c.costCenterHeader, a.CostCenter)
+	}
+	if c.callerHeader != "" && a.Caller != "" {
+		req.Header.Set(c.callerHeader, a.Caller)
+	}
+}
+
+// tagAttribution add the context attribution to the request tags
+func tagAttribution(req *http.Request) {
+	a := AttributionFromContext(req.Context())
+	tags := Tags{}
+	if a.CostCenter != "" {
+		tags[CostCenterTag] = a.CostCenter
+	}
+	if a.Caller != "" {
+		tags[CallerTag] = a.Caller
+	}
+	if len(tags) > 0 {
+		*req = *req.WithContext(ContextWithTags(req.Context(), tags))
+	}
+}
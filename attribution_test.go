@@ -0,0 +1,110 @@
+package go_http_client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var testAttribution = Attribution{CostCenter: "cc-42", Caller: "billing"}
+
+func attributionServer(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestAttributionStats(t *testing.T) {
+	srv := attributionServer(t)
+	c := NewClient(srv.URL)
+	ctx := ContextWithAttribution(context.Background(), testAttribution)
+
+	var out map[string]interface{}
+	if err := c.GetJson(ctx, "/users/42", &out); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.GetJson(context.Background(), "/users/43", &out); err != nil {
+		t.Fatal(err)
+	}
+
+	h := c.Stats()["GET /users/{id}"]
+	if h.Count != 2 {
+		t.Fatalf("count = %v, want 2", h.Count)
+	}
+	if len(h.Attributions) != 1 || h.Attributions[testAttribution] != 1 {
+		t.Errorf("attributions = %v, want %v: 1", h.Attributions, testAttribution)
+	}
+}
+
+func TestAttributionHookTags(t *testing.T) {
+	srv := attributionServer(t)
+	var tags Tags
+	c := NewClient(srv.URL, OnResponse(func(req *http.Request, resp *Response) {
+		tags = TagsFromContext(req.Context())
+	}))
+
+	ctx := ContextWithAttribution(context.Background(), testAttribution)
+	if err := c.Get(ctx, "/"); err != nil {
+		t.Fatal(err)
+	}
+	if tags[CostCenterTag] != "cc-42" || tags[CallerTag] != "billing" {
+		t.Errorf("tags = %v", tags)
+	}
+}
+
+func TestAttributionLogFields(t *testing.T) {
+	srv := attributionServer(t)
+	log := newRecordingLogger()
+	c := NewClient(srv.URL, WithLog(log), WithDebug(true))
+
+	ctx := ContextWithAttribution(context.Background(), testAttribution)
+	if err := c.Get(ctx, "/"); err != nil {
+		t.Fatal(err)
+	}
+	entries := log.logged()
+	if len(entries) == 0 {
+		t.Fatal("nothing logged")
+	}
+	for _, e := range entries {
+		if e.fields[CostCenterTag] != "cc-42" || e.fields[CallerTag] != "billing" {
+			t.Errorf("%v entry fields = %v", e.level, e.fields)
+		}
+	}
+}
+
+func TestAttributionArchive(t *testing.T) {
+	srv := attributionServer(t)
+	dir := t.TempDir()
+	a, err := NewArchiver(ArchiveConfig{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	c := NewClient(srv.URL, WithArchive("", a))
+
+	ctx := ContextWithAttribution(context.Background(), testAttribution)
+	if err := c.Get(ctx, "/"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, archiveFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rec archiveRecord
+	if err := json.Unmarshal(data, &rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.CostCenter != "cc-42" || rec.Caller != "billing" {
+		t.Errorf("record attribution = %q, %q", rec.CostCenter, rec.Caller)
+	}
+	if rec.Tags[CallerTag] != "billing" {
+		t.Errorf("record tags = %v", rec.Tags)
+	}
+}
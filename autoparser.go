@@ -0,0 +1,99 @@
+package go_http_client
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sync"
+)
+
+// ParserFactory returns the parser decoding a response into dst
+type ParserFactory func(dst interface{}) ResponseParser
+
+type registeredParser struct {
+	mediaType string
+	factory   ParserFactory
+}
+
+// ParserRegistry maps media types to parser factories for AutoParser
+type ParserRegistry struct {
+	mu      sync.RWMutex
+	parsers []registeredParser
+}
+
+// NewParserRegistry returns a registry handling JSON (including +json
+// types), XML (including +xml types and text/xml), plain text and octet
+// streams. Text and octet streams decode into a *string, a *[]byte or an
+// io.Writer
+func NewParserRegistry() *ParserRegistry {
+	r := &ParserRegistry{}
+	r.Register(MediaTypeOctetStream, rawParser)
+	r.Register(MediaTypePlainText, rawParser)
+	r.Register("text/xml", XmlParser)
+	r.Register(MediaTypeXML, XmlParser)
+	r.Register(MediaTypeJSON, JsonParser)
+	return r
+}
+
+// Register add or replace the parser factory of a media type, which may be a
+// wildcard such as "text/*". Entries registered later are tried first
+func (r *ParserRegistry) Register(mediaType string, factory ParserFactory) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, p := range r.parsers {
+		if p.mediaType == mediaType {
+			r.parsers = append(r.parsers[:i], r.parsers[i+1:]...)
+			break
+		}
+	}
+	r.parsers = append(r.parsers, registeredParser{mediaType: mediaType, factory: factory})
+}
+
+func (r *ParserRegistry) lookup(mt string) (ParserFactory, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for i := len(r.parsers) - 1; i >= 0; i-- {
+		if mediaTypeMatch(r.parsers[i].mediaType, mt) {
+			return r.parsers[i].factory, true
+		}
+	}
+	return nil, false
+}
+
+// AutoParser decode the response into dst with the parser registered for its
+// Content-Type. Responses without Content-Type are treated as octet streams
+func AutoParser(registry *ParserRegistry, dst interface{}) ResponseParser {
+	return func(resp *http.Response) (e error) {
+		if resp == nil || registry == nil || dst == nil {
+			return fmt.Errorf("AutoParser function error: %v | %v", resp, dst)
+		}
+
+		mt, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+		if mt == "" {
+			mt = MediaTypeOctetStream
+		}
+		factory, ok := registry.lookup(mt)
+		if !ok {
+			return fmt.Errorf("unexpected response content type: %q", mt)
+		}
+		return factory(dst)(resp)
+	}
+}
+
+func rawParser(dst interface{}) ResponseParser {
+	switch t := dst.(type) {
+	case *string:
+		return RawStringParser(t)
+	case *[]byte:
+		return RawBodyParser(t)
+	case io.Writer:
+		return StreamParser(func(r io.Reader) error {
+			_, err := io.Copy(t, r)
+			return err
+		})
+	}
+	return func(resp *http.Response) error {
+		return fmt.Errorf("can't decode %v response into %T", resp.Header.Get("Content-Type"), dst)
+	}
+}
@@ -0,0 +1,194 @@
+package go_http_client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// ErrBatchItemTooLarge is returned when a single item exceeds the batch limit
+var ErrBatchItemTooLarge = errors.New("batch item exceeds the payload limit")
+
+// BatchConfig sets how DoBatch splits a request
+type BatchConfig struct {
+	// MaxBytes is the maximum encoded body size of one request, 0 for no limit
+	MaxBytes int
+	// MaxItems is the maximum number of items in one request, 0 for no limit
+	MaxItems int
+	// Parallelism is the number of requests sent concurrently, at least 1
+	Parallelism int
+	// ContinueOnError send the remaining requests after a failure instead of
+	// cancelling them
+	ContinueOnError bool
+}
+
+// WithBatchConfig set the batch limits of an operation, key is an operation
+// name or a path prefix as for WithOperationTimeout
+func WithBatchConfig(key string, cfg BatchConfig) Option {
+	return func(c *Client) {
+		if c.batchConfigs == nil {
+			c.batchConfigs = make(map[string]BatchConfig)
+		}
+		c.batchConfigs[key] = cfg
+	}
+}
+
+// BatchChunkError is the failure of one of the requests of a batch
+type BatchChunkError struct {
+	// Offset and Count locate the items of the failed request in the input
+	Offset int
+	Count  int
+	Err    error
+}
+
+// BatchError collects the failed requests of a batch
+type BatchError struct {
+	Errors []BatchChunkError
+}
+
+func (t BatchError) Error() string {
+	msgs := make([]string, 0, len(t.Errors))
+	for _, e := range t.Errors {
+		msgs = append(msgs, fmt.Sprintf("items %v-%v: %v", e.Offset, e.Offset+e.Count-1, e.Err))
+	}
+	return fmt.Sprintf("batch error: %v of the requests failed: %v", len(t.Errors), strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the first chunk error
+func (t BatchError) Unwrap() error {
+	if len(t.Errors) == 0 {
+		return nil
+	}
+	return t.Errors[0].Err
+}
+
+// DoBatch send items as JSON arrays, split into as many requests as needed to
+// respect the batch config of the operation (see WithBatchConfig, the
+// operation is taken from ctx). Every response must be a JSON array, the
+// decoded results are concatenated in input order. With ContinueOnError the
+// results of the successful requests are returned along with the BatchError
+func DoBatch[In any, Out any](ctx context.Context, c *Client, method, path string, items []In, options ...RequestOption) ([]Out, error) {
+	cfg := c.batchConfigFor(ctx, path)
+
+	chunks, err := splitBatch(items, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([][]Out, len(chunks))
+	errs := make([]error, len(chunks))
+
+	parallelism := cfg.Parallelism
+	if parallelism < 1 {
+		parallelism = 1
+	}
+	sem := make(chan struct{}, parallelism)
+	wg := sync.WaitGroup{}
+	for i := range chunks {
+		sem <- struct{}{}
+		if ctx.Err() != nil {
+			errs[i] = ctx.Err()
+			<-sem
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			opts := append([]RequestOption{
+				WithBodyOpt(bytes.NewReader(chunks[i].body)),
+				WithHeadersOpt(http.Header{"Content-Type": {MediaTypeJSON}}),
+			}, options...)
+			errs[i] = c.DoRequestJson(ctx, method, path, &results[i], opts...)
+			if errs[i] != nil && !cfg.ContinueOnError {
+				cancel()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	out := make([]Out, 0, len(items))
+	batchErr := BatchError{}
+	for i, chunk := range chunks {
+		if errs[i] != nil {
+			batchErr.Errors = append(batchErr.Errors, BatchChunkError{Offset: chunk.offset, Count: chunk.count, Err: errs[i]})
+			continue
+		}
+		out = append(out, results[i]...)
+	}
+
+	if len(batchErr.Errors) > 0 {
+		return out, batchErr
+	}
+	return out, nil
+}
+
+func (c *Client) batchConfigFor(ctx context.Context, path string) BatchConfig {
+	p := path
+	if u, err := url.Parse(c.endpoint + path); err == nil {
+		p = u.Path
+	}
+	cfg, _, _ := lookupOperation(c.batchConfigs, OperationFromContext(ctx), p)
+	return cfg
+}
+
+type batchChunk struct {
+	offset int
+	count  int
+	body   []byte
+}
+
+// splitBatch greedily pack the encoded items into JSON arrays within limits
+func splitBatch[In any](items []In, cfg BatchConfig) ([]batchChunk, error) {
+	chunks := make([]batchChunk, 0, 1)
+	current := batchChunk{body: []byte{'['}}
+
+	flush := func() {
+		current.body = append(current.body, ']')
+		chunks = append(chunks, current)
+	}
+
+	for i, item := range items {
+		data, err := json.Marshal(item)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal batch item %v: %w", i, err)
+		}
+		if cfg.MaxBytes > 0 && len(data)+2 > cfg.MaxBytes {
+			return nil, fmt.Errorf("%w: item %v is %v bytes", ErrBatchItemTooLarge, i, len(data))
+		}
+
+		size := len(current.body) + len(data) + 1
+		if current.count > 0 {
+			size++
+		}
+		full := cfg.MaxItems > 0 && current.count >= cfg.MaxItems
+		if current.count > 0 && (full || (cfg.MaxBytes > 0 && size > cfg.MaxBytes)) {
+			flush()
+			current = batchChunk{offset: i, body: []byte{'['}}
+		}
+
+		if current.count > 0 {
+			current.body = append(current.body, ',')
+		}
+		current.body = append(current.body, data...)
+		current.count++
+	}
+
+	if current.count > 0 || len(chunks) == 0 {
+		flush()
+	}
+	return chunks, nil
+}
@@ -0,0 +1,28 @@
+// Package cbor adds CBOR (RFC 8949) request bodies and response parsers to the
+// client. It lives in its own module so the CBOR library is only pulled in by
+// the users who need it
+package cbor
+
+import (
+	"io"
+
+	cl "github.com/Traumeel/go-http-client"
+	cborv2 "github.com/fxamacker/cbor/v2"
+)
+
+// ContentType is the media type of CBOR bodies
+const ContentType = "application/cbor"
+
+func decode(r io.Reader, v interface{}) error {
+	return cborv2.NewDecoder(r).Decode(v)
+}
+
+// Parser decode the CBOR response into dst
+func Parser(dst interface{}) cl.ResponseParser {
+	return cl.DecodingParser(dst, decode)
+}
+
+// WithBodyOpt marshal v as the CBOR request body and set the Content-Type
+func WithBodyOpt(v interface{}) cl.RequestOption {
+	return cl.WithEncodedBodyOpt(v, ContentType, cborv2.Marshal)
+}
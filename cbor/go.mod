@@ -0,0 +1,10 @@
+module github.com/Traumeel/go-http-client/cbor
+
+go 1.18
+
+require (
+	github.com/Traumeel/go-http-client v0.1.0
+	github.com/fxamacker/cbor/v2 v2.9.4
+)
+
+require github.com/x448/float16 v0.8.4 // indirect
@@ -0,0 +1,28 @@
+// Package charset transcodes responses in legacy charsets such as ISO-8859-1
+// or Shift_JIS to UTF-8. It lives in its own module so golang.org/x/text is
+// only pulled in by the users who need it
+package charset
+
+import (
+	"fmt"
+	"io"
+
+	cl "github.com/Traumeel/go-http-client"
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// Decoder transcode r from charset to UTF-8, charset being any name or alias
+// of the WHATWG encoding standard
+func Decoder(charset string, r io.Reader) (io.Reader, error) {
+	enc, err := htmlindex.Get(charset)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported charset %q: %w", charset, err)
+	}
+	return enc.NewDecoder().Reader(r), nil
+}
+
+// WithTranscoding transcode the responses of the client to UTF-8 with
+// Decoder, see cl.WithCharsetDecoder
+func WithTranscoding() cl.Option {
+	return cl.WithCharsetDecoder(Decoder)
+}
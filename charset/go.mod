@@ -0,0 +1,8 @@
+module github.com/Traumeel/go-http-client/charset
+
+go 1.18
+
+require (
+	github.com/Traumeel/go-http-client v0.1.0
+	golang.org/x/text v0.21.0
+)
@@ -1,17 +1,17 @@
 package go_http_client
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
-	"net/http/httputil"
 	"net/url"
+	"strings"
+	"sync"
 	"time"
-
-	log "github.com/sirupsen/logrus"
 )
 
 type httpClient interface {
@@ -23,15 +23,23 @@ type ResponseParser func(*http.Response) error
 type ValidateResponse func(*http.Response) error
 type Option func(*Client)
 
-// WithHttpClient setup a custom http client
+// WithHttpClient setup a custom http client. Requests are then only bounded
+// by its own timeout unless one is set with WithTimeout
 func WithHttpClient(client httpClient) Option {
 	return func(c *Client) {
 		c.httpClient = client
+		if !c.timeoutSet {
+			c.timeout = 0
+		}
 	}
 }
 
-// WithRequestFilters setup a custom logger
-func WithLog(l *log.Logger) Option {
+// WithLog setup a custom logger, e.g. one of the logrus, slog, zap or
+// zerolog adapters. The default writes to stderr with the standard library
+// logger, debug entries included. In debug mode each response is summed up at
+// info level, failures are logged at warning level and the request and
+// response dumps at debug level
+func WithLog(l Logger) Option {
 	return func(c *Client) {
 		c.log = l
 	}
@@ -54,7 +62,32 @@ func RequestBasicAuthOption(username, password string) Option {
 	}
 }
 
-// WithDebug enable debugging for the client
+// WithDefaultHeaders add headers to all the requests, they are applied
+// before the global and per-request options
+func WithDefaultHeaders(header http.Header) Option {
+	return func(c *Client) {
+		if c.defaultHeaders == nil {
+			c.defaultHeaders = make(http.Header, len(header))
+		}
+		for k, vs := range header {
+			for _, v := range vs {
+				c.defaultHeaders.Add(k, v)
+			}
+		}
+	}
+}
+
+// WithDrainLimit set how many unread response bytes are discarded before the
+// body is closed so the connection can be reused. Bodies with more left
+// over are closed right away, dropping the connection. 0 disables draining
+func WithDrainLimit(n int64) Option {
+	return func(c *Client) {
+		c.drainLimit = n
+	}
+}
+
+// WithDebug enable debugging for the client, see WithDebugOpt to debug a
+// single request. The dumps are logged at debug level, see WithLog
 func WithDebug(b bool) Option {
 	return func(c *Client) {
 		c.debug = b
@@ -63,55 +96,223 @@ func WithDebug(b bool) Option {
 
 // WithResponseValidator set a custom response validator function
 func WithResponseValidator(v ValidateResponse) Option {
+	return WithResponseValidators(v)
+}
+
+// WithResponseValidators set the response validators, run in order until one
+// fails, e.g. a status check followed by content type and schema checks.
+// Validators must leave the body unread when they accept the response
+func WithResponseValidators(v ...ValidateResponse) Option {
 	return func(c *Client) {
-		c.validateResponseFn = v
+		c.validators = append([]ValidateResponse(nil), v...)
+	}
+}
+
+// validateChain run the validators in order
+func validateChain(validators []ValidateResponse) ValidateResponse {
+	return func(resp *http.Response) error {
+		for _, v := range validators {
+			if err := validate(v, resp); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+type validatorKey struct{}
+
+// WithValidatorOpt validate the response of this request with v instead of
+// the validator of the client, e.g. to accept a 404 in an existence check
+func WithValidatorOpt(v ValidateResponse) RequestOption {
+	return func(req *http.Request) (e error) {
+		if v == nil || req == nil {
+			return fmt.Errorf("WithValidatorOpt error: %v | %v", req, v)
+		}
+		*req = *req.WithContext(context.WithValue(req.Context(), validatorKey{}, v))
+		return
+	}
+}
+
+// validatorFor returns the validator of the request
+func (c *Client) validatorFor(req *http.Request) ValidateResponse {
+	if v, ok := req.Context().Value(validatorKey{}).(ValidateResponse); ok {
+		return validateChain([]ValidateResponse{v})
 	}
+	return validateChain(c.validators)
 }
 
+// DefaultDrainLimit is the default amount of unread response body drained
+// before closing, see WithDrainLimit
+const DefaultDrainLimit = 64 << 10
+
 type Client struct {
-	endpoint            string
-	log                 *log.Logger
-	httpClient          httpClient
-	requestOptionsChain []RequestOption
-	validateResponseFn  ValidateResponse
-	debug               bool
+	endpoint             string
+	log                  Logger
+	httpClient           httpClient
+	journal              *journal
+	breakers             *breakers
+	stats                *stats
+	clock                Clock
+	policies             *PolicyStore
+	requestOptionsChain  []RequestOption
+	defaultHeaders       http.Header
+	validators           []ValidateResponse
+	debug                bool
+	debugSampleRate      float64
+	curlOnError          bool
+	har                  *HARRecorder
+	archives             map[string]*Archiver
+	requestIDHeader      string
+	requestIDGen         func() string
+	correlationHeader    string
+	correlationKey       interface{}
+	timeout              time.Duration
+	timeoutSet           bool
+	operationTimeouts    map[string]time.Duration
+	batchConfigs         map[string]BatchConfig
+	drainLimit           int64
+	maxResponseBytes     int64
+	maxDecompressedBytes int64
+	maxErrorBodyBytes    int64
+	cookieJar            http.CookieJar
+	encodingMode         EncodingMode
+	decoders             map[string]Decoder
+	errorDecoders        map[int]func() error
+	errorMapper          func(*http.Response) error
+	charsetDecoder       CharsetDecoder
+	strict               *StrictMode
+	costCenterHeader     string
+	requestHashHeader    string
+	callerHeader         string
+	propagateTrace       bool
+	spanStarters         []SpanStarter
+	contractRecorder     ContractRecorder
+	contractSampleRate   float64
+	hooks                hooks
+	redactor             *redactor
+	pendingLogs          []func(Logger)
 }
 
 func NewClient(endpoint string, options ...Option) *Client {
 	c := &Client{
 		endpoint:            endpoint,
-		httpClient:          &http.Client{Timeout: 30 * time.Second},
-		log:                 log.New(),
+		httpClient:          &http.Client{},
+		journal:             newJournal(),
+		breakers:            newBreakers(),
+		stats:               newStats(),
+		clock:               systemClock{},
+		timeout:             DefaultTimeout,
+		log:                 NewStdLogger(nil, true),
 		requestOptionsChain: make([]RequestOption, 0),
-		validateResponseFn:  ResponseValidator,
+		validators:          []ValidateResponse{ResponseValidator},
 		debug:               false,
+		drainLimit:          DefaultDrainLimit,
+		redactor:            newRedactor(),
+		maxErrorBodyBytes:   DefaultMaxErrorBodyBytes,
+		debugSampleRate:     1,
 	}
 
+	c.applyOptions(options)
+	return c
+}
+
+// applyOptions apply the options and then write the logs they deferred, so
+// they go to the logger set by WithLog whatever the option order
+func (c *Client) applyOptions(options []Option) {
 	for _, opt := range options {
 		opt(c)
 	}
+	for _, write := range c.pendingLogs {
+		write(c.log)
+	}
+	c.pendingLogs = nil
+}
 
-	return c
+// logLater log with the logger of the client once all the options are applied
+func (c *Client) logLater(write func(Logger)) {
+	c.pendingLogs = append(c.pendingLogs, write)
+}
+
+// WithBasePath returns a child client which shares the transport and options
+// of the parent but prefixes all request paths with basePath
+func (c *Client) WithBasePath(basePath string) *Client {
+	child := c.clone()
+	child.endpoint = c.endpoint + strings.TrimSuffix(basePath, "/")
+	return child
+}
+
+func copyMap[K comparable, V any](m map[K]V) map[K]V {
+	if m == nil {
+		return nil
+	}
+	cp := make(map[K]V, len(m))
+	for k, v := range m {
+		cp[k] = v
+	}
+	return cp
+}
+
+// Clone returns a shallow copy of the client with the given options applied
+// on top of the current configuration. The underlying http client is shared
+func (c *Client) Clone(options ...Option) *Client {
+	child := c.clone()
+	child.applyOptions(options)
+	return child
+}
+
+func (c *Client) clone() *Client {
+	child := *c
+	child.requestOptionsChain = append(make([]RequestOption, 0, len(c.requestOptionsChain)), c.requestOptionsChain...)
+	child.defaultHeaders = c.defaultHeaders.Clone()
+	child.operationTimeouts = copyMap(c.operationTimeouts)
+	child.batchConfigs = copyMap(c.batchConfigs)
+	child.decoders = copyMap(c.decoders)
+	child.hooks = c.hooks.clone()
+	child.spanStarters = append([]SpanStarter(nil), c.spanStarters...)
+	child.redactor = c.redactor.clone()
+	child.errorDecoders = copyMap(c.errorDecoders)
+	child.archives = copyMap(c.archives)
+	return &child
 }
 
-// WithQueryOpt add query to request
+// WithQueryOpt add query to request, merging it with the query parameters
+// already in the path or set by earlier options
 func WithQueryOpt(query url.Values) RequestOption {
 	return func(req *http.Request) (e error) {
 		if query == nil || req == nil {
 			return fmt.Errorf("WithQueryOpt error: %v | %v", req, query)
 		}
+		q := req.URL.Query()
+		for k, vs := range query {
+			for _, v := range vs {
+				q.Add(k, v)
+			}
+		}
+		req.URL.RawQuery = q.Encode()
+		return
+	}
+}
+
+// WithQueryReplaceOpt replace the request query
+func WithQueryReplaceOpt(query url.Values) RequestOption {
+	return func(req *http.Request) (e error) {
+		if query == nil || req == nil {
+			return fmt.Errorf("WithQueryReplaceOpt error: %v | %v", req, query)
+		}
 		req.URL.RawQuery = query.Encode()
 		return
 	}
 }
 
-// WithHeadersOpt add headers to a request
+// WithHeadersOpt add headers to a request, merging them with the headers set
+// by earlier options
 func WithHeadersOpt(header http.Header) RequestOption {
 	return func(req *http.Request) (e error) {
 		if header == nil || req == nil {
 			return fmt.Errorf("WithHeadersOpt error: %v | %v", req, header)
 		}
-		for k, vs := range header{
+		for k, vs := range header {
 			for _, v := range vs {
 				req.Header.Add(k, v)
 			}
@@ -120,12 +321,27 @@ func WithHeadersOpt(header http.Header) RequestOption {
 	}
 }
 
+// WithHeadersReplaceOpt replace all the request headers, including those set
+// by earlier options such as basic auth
+func WithHeadersReplaceOpt(header http.Header) RequestOption {
+	return func(req *http.Request) (e error) {
+		if header == nil || req == nil {
+			return fmt.Errorf("WithHeadersReplaceOpt error: %v | %v", req, header)
+		}
+		req.Header = header.Clone()
+		return
+	}
+}
+
 // RequestBodyOption add body to a request
 func WithBodyOpt(body io.Reader) RequestOption {
 	return func(req *http.Request) (e error) {
 		if body == nil || req == nil {
 			return fmt.Errorf("WithBodyOpt error: %v | %v", req, body)
 		}
+		if isStrict(req.Context()) && hasBody(req) {
+			return fmt.Errorf("%w: WithBodyOpt replaces the body set by an earlier option", ErrStrictMode)
+		}
 		nreq, err := http.NewRequest("", req.URL.String(), body)
 		if err != nil {
 			return err
@@ -138,6 +354,24 @@ func WithBodyOpt(body io.Reader) RequestOption {
 	}
 }
 
+// WithJsonBodyOpt marshal v as the request body and set the Content-Type
+func WithJsonBodyOpt(v interface{}) RequestOption {
+	return func(req *http.Request) (e error) {
+		if req == nil {
+			return fmt.Errorf("WithJsonBodyOpt error: %v | %v", req, v)
+		}
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request body: %w", err)
+		}
+		if err := WithBodyOpt(bytes.NewReader(data))(req); err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", MediaTypeJSON)
+		return
+	}
+}
+
 func RawStringParser(dst *string) ResponseParser {
 	return func(resp *http.Response) (e error) {
 		if resp == nil || dst == nil {
@@ -168,70 +402,195 @@ func RawBodyParser(dst *[]byte) ResponseParser {
 	}
 }
 
-func NoBodyParser(log *log.Logger) ResponseParser {
+// StreamParser hand the response body to fn as a stream, so large responses
+// can be processed without buffering them. The body is closed by the client
+// once fn returns
+func StreamParser(fn func(io.Reader) error) ResponseParser {
+	return func(resp *http.Response) (e error) {
+		if resp == nil || fn == nil {
+			return fmt.Errorf("StreamParser function error: %v", resp)
+		}
+		return fn(resp.Body)
+	}
+}
+
+// NoBodyParser ignore the response body, an unexpected body is dumped to log
+// at debug level with the redaction, body limit and formatter of the client
+func NoBodyParser(log Logger) ResponseParser {
 	return func(resp *http.Response) (e error) {
 		if resp.ContentLength != 0 && log != nil {
-			logResponse(resp, log)
+			r := newRedactor()
+			if resp.Request != nil {
+				r = redactorOf(resp.Request.Context())
+			}
+			logResponseDump(resp, 0, log, r)
 		}
 		return
 	}
 }
 
+// JsonParser decode the JSON response into dst, see WithDefaultAcceptOpt to
+// accept application/json
 func JsonParser(dst interface{}) ResponseParser {
 	return func(resp *http.Response) (e error) {
 		if resp == nil || dst == nil {
 			return fmt.Errorf("JsonParser function error: %v | %v", resp, dst)
 		}
-		return json.NewDecoder(resp.Body).Decode(dst)
+		if err := json.NewDecoder(resp.Body).Decode(dst); err != nil {
+			return err
+		}
+		setResponseMetadata(dst, resp)
+		return
 	}
 }
 
 func ResponseValidator(resp *http.Response) error {
 	if resp.StatusCode > 300 {
-		body, err := ioutil.ReadAll(resp.Body)
+		statusErr := newStatusCodeError(resp)
+		body, truncated, err := readErrorBody(resp)
 		if err != nil {
-			return fmt.Errorf("failed to read response body: %w", err)
+			statusErr.Err = fmt.Errorf("failed to read response body: %w", err)
+			return statusErr
+		}
+		statusErr.Body, statusErr.RawBody, statusErr.Truncated = string(body), body, truncated
+		if truncated {
+			statusErr.Body += errorBodyTruncated
 		}
 
-		return StatusCodeError{
-			Code:   resp.StatusCode,
-			Status: resp.Status,
-			Body:   string(body),
+		if mediaType(resp.Header) == MediaTypeProblemJSON {
+			if p, ok := newProblemDetails(statusErr, body); ok {
+				return p
+			}
 		}
+		return statusErr
 	}
 
 	return nil
 }
 
-func logRequest(req *http.Request, log *log.Logger) {
-	requestDump, err := httputil.DumpRequestOut(req, true)
+// logRequest dump req at debug level
+func logRequest(req *http.Request, log Logger, r *redactor) {
+	fields := Fields{"method": req.Method, "url": RedactedURL(req)}
+	if r.formatter != nil {
+		dump, err := r.requestDump(req)
+		if err != nil {
+			withError(log, err).Errorf("failed to dump http request for logging")
+			return
+		}
+		msg, extra := r.formatter.FormatRequest(dump)
+		log.WithFields(logFields(req.Context())).WithFields(fields).WithFields(extra).Debugf("%s", msg)
+		return
+	}
+
+	requestDump, err := r.dumpRequest(req)
 	if err != nil {
-		log.WithError(err).Error("failed to dump http request for logging")
+		withError(log, err).Errorf("failed to dump http request for logging")
 		return
 	}
-	log.Infof(string(requestDump))
+	log.WithFields(logFields(req.Context())).WithFields(fields).Debugf("%s", requestDump)
 }
 
-func logResponse(resp *http.Response, log *log.Logger) {
-	respDump, err := httputil.DumpResponse(resp, true)
+// logResponse log a one line summary of resp at info level and its dump at
+// debug level, d is the time until its headers were received or 0 when
+// unknown
+func logResponse(resp *http.Response, d time.Duration, log Logger, r *redactor) {
+	fields, l := responseFields(resp, log)
+	if d > 0 {
+		l.WithFields(fields).WithFields(Fields{"duration": d}).Infof("http response %v", resp.Status)
+	} else {
+		l.WithFields(fields).Infof("http response %v", resp.Status)
+	}
+	logResponseDump(resp, d, log, r)
+}
+
+// responseFields returns the log fields of resp and log with the fields of
+// its request context
+func responseFields(resp *http.Response, log Logger) (Fields, Logger) {
+	fields := Fields{"status": resp.StatusCode}
+	if resp.Request != nil {
+		log = log.WithFields(logFields(resp.Request.Context()))
+		fields["method"], fields["url"] = resp.Request.Method, RedactedURL(resp.Request)
+	}
+	return fields, log
+}
+
+// logResponseDump log the redacted dump of resp at debug level
+func logResponseDump(resp *http.Response, d time.Duration, log Logger, r *redactor) {
+	fields, log := responseFields(resp, log)
+	if r.formatter != nil {
+		dump, err := r.responseDump(resp, d)
+		if err != nil {
+			withError(log, err).Errorf("failed to dump http response for logging")
+			return
+		}
+		msg, extra := r.formatter.FormatResponse(dump)
+		log.WithFields(fields).WithFields(extra).Debugf("%s", msg)
+		return
+	}
+
+	respDump, err := r.dumpResponse(resp)
 	if err != nil {
-		log.WithError(err).Error("failed to dump http response for logging")
+		withError(log, err).Errorf("failed to dump http response for logging")
 		return
 	}
-	log.Infof(string(respDump))
+	log.WithFields(fields).Debugf("%s", respDump)
+}
+
+// logFailure log the error a debugged request failed with at warning level
+func logFailure(req *http.Request, err error, log Logger) {
+	withError(log, err).WithFields(logFields(req.Context())).WithFields(Fields{
+		"method": req.Method,
+		"url":    RedactedURL(req),
+	}).Warnf("http request failed")
 }
 
 // StatusCodeError represents an http response error
 type StatusCodeError struct {
-	Code   int
-	Status string
-	Body   string
+	Code    int
+	Status  string
+	Body    string
+	RawBody []byte
+	// Truncated is set when the body was cut at the WithMaxErrorBodyBytes
+	// limit, Body then ends with a truncation marker
+	Truncated bool
+	Header    http.Header
+	Method    string
+	URL       string
+	// RequestID is the first of the RequestIDHeaders set on the response,
+	// or the ID attached with WithRequestID
+	RequestID string
+	// Err is the failure to read the body, if any
+	Err error
+}
+
+func newStatusCodeError(resp *http.Response) StatusCodeError {
+	statusErr := StatusCodeError{
+		Code:      resp.StatusCode,
+		Status:    resp.Status,
+		Header:    resp.Header,
+		RequestID: requestID(resp.Header),
+	}
+	if resp.Request != nil {
+		statusErr.Method = resp.Request.Method
+		statusErr.URL = RedactedURL(resp.Request)
+		if statusErr.RequestID == "" {
+			statusErr.RequestID = RequestIDFromContext(resp.Request.Context())
+		}
+	}
+	return statusErr
 }
 
 func (t StatusCodeError) Error() string {
+	if t.Err != nil {
+		return fmt.Sprintf("error: %v | %v | %v", t.Code, t.Status, t.Err)
+	}
 	return fmt.Sprintf("error: %v | %v | %v", t.Code, t.Status, t.Body)
 }
 
+func (t StatusCodeError) Unwrap() error {
+	return t.Err
+}
+
 func (t StatusCodeError) HTTPStatusCode() int {
 	return t.Code
 }
@@ -240,14 +599,63 @@ func (c *Client) GetJson(ctx context.Context, path string, intf interface{}, opt
 	return c.DoRequestJson(ctx, http.MethodGet, path, intf, options...)
 }
 
+// DoRequestJson decode the JSON response into intf. The Accept header
+// defaults to application/json
 func (c *Client) DoRequestJson(ctx context.Context, method, path string, intf interface{}, options ...RequestOption) error {
-	return c.DoRequest(ctx, method, path, JsonParser(intf), options...)
+	opts := append(options[:len(options):len(options)], WithDefaultAcceptOpt(MediaTypeJSON))
+	return c.DoRequest(ctx, method, path, JsonParser(intf), opts...)
+}
+
+// PostJson send in as a JSON body and decode the JSON response into out
+func (c *Client) PostJson(ctx context.Context, path string, in, out interface{}, options ...RequestOption) error {
+	return c.DoRequestJsonBody(ctx, http.MethodPost, path, in, out, options...)
+}
+
+// PutJson send in as a JSON body and decode the JSON response into out
+func (c *Client) PutJson(ctx context.Context, path string, in, out interface{}, options ...RequestOption) error {
+	return c.DoRequestJsonBody(ctx, http.MethodPut, path, in, out, options...)
+}
+
+// PatchJson send in as a JSON body and decode the JSON response into out
+func (c *Client) PatchJson(ctx context.Context, path string, in, out interface{}, options ...RequestOption) error {
+	return c.DoRequestJsonBody(ctx, http.MethodPatch, path, in, out, options...)
+}
+
+// DoRequestJsonBody send in as a JSON body and decode the JSON response into
+// out. The Accept header defaults to application/json
+func (c *Client) DoRequestJsonBody(ctx context.Context, method, path string, in, out interface{}, options ...RequestOption) error {
+	opts := append([]RequestOption{WithJsonBodyOpt(in)}, options...)
+	return c.DoRequestJson(ctx, method, path, out, opts...)
 }
 
 func (c *Client) Get(ctx context.Context, path string, options ...RequestOption) error {
 	return c.DoRequestNoBody(ctx, http.MethodGet, path, options...)
 }
 
+func (c *Client) Post(ctx context.Context, path string, options ...RequestOption) error {
+	return c.DoRequestNoBody(ctx, http.MethodPost, path, options...)
+}
+
+func (c *Client) Put(ctx context.Context, path string, options ...RequestOption) error {
+	return c.DoRequestNoBody(ctx, http.MethodPut, path, options...)
+}
+
+func (c *Client) Patch(ctx context.Context, path string, options ...RequestOption) error {
+	return c.DoRequestNoBody(ctx, http.MethodPatch, path, options...)
+}
+
+func (c *Client) Delete(ctx context.Context, path string, options ...RequestOption) error {
+	return c.DoRequestNoBody(ctx, http.MethodDelete, path, options...)
+}
+
+func (c *Client) Head(ctx context.Context, path string, options ...RequestOption) error {
+	return c.DoRequestNoBody(ctx, http.MethodHead, path, options...)
+}
+
+func (c *Client) Options(ctx context.Context, path string, options ...RequestOption) error {
+	return c.DoRequestNoBody(ctx, http.MethodOptions, path, options...)
+}
+
 func (c *Client) DoRequestNoBody(ctx context.Context, method, path string, options ...RequestOption) error {
 	return c.DoRequest(ctx, method, path, NoBodyParser(c.log), options...)
 }
@@ -256,35 +664,42 @@ func (c *Client) DoRequestString(ctx context.Context, method, path string, out *
 	return c.DoRequest(ctx, method, path, RawStringParser(out), options...)
 }
 
+// DoRequestStream hand the response body to fn as a stream. The request isn't
+// bounded by the default timeout, see WithTimeout
+func (c *Client) DoRequestStream(ctx context.Context, method, path string, fn func(io.Reader) error, options ...RequestOption) error {
+	return c.DoRequest(asTransfer(ctx), method, path, StreamParser(fn), options...)
+}
+
+// DownloadFile copy the response body to wr. The request isn't bounded by the
+// default timeout, see WithTimeout
 func (c *Client) DownloadFile(ctx context.Context, method, path string, wr io.Writer, options ...RequestOption) error {
-	req, err := http.NewRequest(method, c.endpoint+path, nil)
+	req, err := c.newRequest(asTransfer(ctx), method, c.endpoint+path, options)
 	if err != nil {
 		return err
 	}
 
-	//apply global request options
-	for _, opt := range c.requestOptionsChain {
-		if err := opt(req); err != nil {
-			return fmt.Errorf("failed to apply global request option: %w", err)
-		}
+	req, entry, err := c.journal.start(req)
+	if err != nil {
+		return err
 	}
+	defer entry.done()
 
-	//apply custom request options
-	for _, opt := range options {
-		if err := opt(req); err != nil {
-			return fmt.Errorf("failed to apply global request option: %w", err)
-		}
-	}
+	req, cancel := c.withDeadline(req, c.timeoutFor(req))
+	defer cancel()
 
-	req = req.WithContext(ctx)
-	resp, err := c.httpClient.Do(req)
+	endSpans := c.startSpans(req)
+	start := time.Now()
+	resp, err := c.do(req)
 	if err != nil {
-		return err
+		endSpans(nil, err)
+		return withRequestID(req, err)
 	}
-	defer resp.Body.Close()
+	endSpans(newResponse(req, resp, time.Since(start)), nil)
+	trackDownload(req, resp)
+	defer c.closeBody(resp.Body)
 
-	if c.debug {
-		logResponse(resp, c.log)
+	if c.debugFor(req) {
+		logResponse(resp, time.Since(start), c.log, c.redactor)
 	}
 
 	if resp.StatusCode != 200 {
@@ -299,43 +714,295 @@ func (c *Client) DownloadFile(ctx context.Context, method, path string, wr io.Wr
 	return nil
 }
 
-func (c *Client) DoRequest(ctx context.Context, method, path string, parser ResponseParser, options ...RequestOption) error {
-	req, err := http.NewRequestWithContext(ctx, method, c.endpoint+path, nil)
+// PrepareRequest build the request the client would send to path, with the
+// default headers, global and request options and cookies applied, for
+// protocols handled outside the client such as WebSocket upgrades
+func (c *Client) PrepareRequest(ctx context.Context, method, path string, options ...RequestOption) (*http.Request, error) {
+	req, err := c.newRequest(ctx, method, c.endpoint+path, options)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	c.addCookies(req)
+	return req, nil
+}
+
+// Transport returns the transport of the underlying http client, so other
+// protocols can share its TLS and proxy settings, or nil when the http client
+// isn't an *http.Client. A nil transport on the http client is
+// http.DefaultTransport
+func (c *Client) Transport() http.RoundTripper {
+	hc, ok := c.httpClient.(*http.Client)
+	if !ok {
+		return nil
+	}
+	if hc.Transport == nil {
+		return http.DefaultTransport
+	}
+	return hc.Transport
+}
+
+func (c *Client) newRequest(ctx context.Context, method, fullURL string, options []RequestOption) (*http.Request, error) {
+	if c.strict != nil {
+		ctx = context.WithValue(ctx, strictKey{}, c.strict)
+	}
+	ctx = context.WithValue(ctx, errorBodyLimitKey{}, c.maxErrorBodyBytes)
+	ctx = context.WithValue(ctx, redactorKey{}, c.redactor)
+	ctx = c.sampleDebug(ctx)
+
+	req, err := http.NewRequestWithContext(ctx, method, fullURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	//apply default headers
+	for k, vs := range c.defaultHeaders {
+		for _, v := range vs {
+			req.Header.Add(k, v)
+		}
 	}
 
 	//apply global request options
 	for _, opt := range c.requestOptionsChain {
-		if err := opt(req); err != nil {
-			return fmt.Errorf("failed to apply global request option: %w", err)
+		if err := applyOption(opt, req); err != nil {
+			return nil, fmt.Errorf("failed to apply global request option: %w", err)
 		}
 	}
 
 	//apply custom request options
 	for _, opt := range options {
-		if err := opt(req); err != nil {
-			return fmt.Errorf("failed to apply global request option: %w", err)
+		if err := applyOption(opt, req); err != nil {
+			return nil, fmt.Errorf("failed to apply request option: %w", err)
+		}
+	}
+
+	if err := gzipRequest(req); err != nil {
+		return nil, err
+	}
+
+	if c.strict != nil {
+		if err := c.strict.checkRequest(req); err != nil {
+			return nil, err
 		}
 	}
 
-	if c.debug {
-		logRequest(req, c.log)
+	c.setAttributionHeaders(req)
+	tagAttribution(req)
+	c.setTraceHeaders(req)
+	c.setCorrelationID(req)
+	c.setRequestID(req)
+
+	if c.requestHashHeader != "" {
+		hash, err := CanonicalRequestHash(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash request: %w", err)
+		}
+		req.Header.Set(c.requestHashHeader, hash)
 	}
 
-	resp, err := c.httpClient.Do(req)
+	trackUpload(req)
+	return req, nil
+}
+
+// do send the request through the http client
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	c.addCookies(req)
+	client := c.httpClient
+	if redirectCapture(req.Context()) != nil {
+		client = noFollow(client)
+	}
+	resp, err := client.Do(req)
 	if err != nil {
-		return err
+		return nil, redactURLError(req, err)
+	}
+	c.storeCookies(req, resp)
+
+	if err := c.decodeBody(req, resp); err != nil {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to decode response body: %w", err)
+	}
+	if err := c.decodeCharset(resp); err != nil {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to decode response charset: %w", err)
+	}
+	c.limitBody(req, resp)
+	return resp, nil
+}
+
+// closeBody drain what is left of body up to the drain limit and close it.
+// The transport only reuses connections whose body was read to EOF
+func (c *Client) closeBody(body io.ReadCloser) {
+	if c.drainLimit > 0 {
+		io.CopyN(ioutil.Discard, body, c.drainLimit+1)
+	}
+	body.Close()
+}
+
+func (c *Client) DoRequest(ctx context.Context, method, path string, parser ResponseParser, options ...RequestOption) error {
+	return c.DoRequestURL(ctx, method, c.endpoint+path, parser, options...)
+}
+
+// DoRequestURL perform a request to fullURL bypassing the client endpoint,
+// e.g. for presigned URLs or links returned by the API. Client options still apply
+func (c *Client) DoRequestURL(ctx context.Context, method, fullURL string, parser ResponseParser, options ...RequestOption) error {
+	_, err := c.doRequest(ctx, method, fullURL, parser, options)
+	return err
+}
+
+// DoRequestMeta perform the request like DoRequest and also return the
+// response metadata. The metadata is returned whenever a response was
+// received, including when validation or parsing failed
+func (c *Client) DoRequestMeta(ctx context.Context, method, path string, parser ResponseParser, options ...RequestOption) (*Response, error) {
+	return c.doRequest(ctx, method, c.endpoint+path, parser, options)
+}
+
+func (c *Client) doRequest(ctx context.Context, method, fullURL string, parser ResponseParser, options []RequestOption) (*Response, error) {
+	resp, meta, captured, err := c.doRaw(ctx, method, fullURL, options)
+	if err != nil || captured {
+		return meta, err
 	}
 	defer resp.Body.Close()
 
-	if c.debug {
-		logResponse(resp, c.log)
+	if err := parse(parser, resp); err != nil {
+		c.hooks.error(resp.Request, meta, err)
+		return meta, err
 	}
+	return meta, nil
+}
 
-	if err := c.validateResponseFn(resp); err != nil {
-		return err
+// DoRequestRaw perform the request applying the options, validation and
+// debug logging and hand back the open response for custom handling. The
+// caller owns the response and must close its body, which also releases the
+// request deadline. The body isn't consumed by the validator on success
+func (c *Client) DoRequestRaw(ctx context.Context, method, path string, options ...RequestOption) (*http.Response, error) {
+	resp, _, _, err := c.doRaw(ctx, method, c.endpoint+path, options)
+	return resp, err
+}
+
+// doRaw send the request and validate the response. On success the body is
+// left open and closing it drains it and releases the request deadline.
+// captured reports a redirect captured by WithCaptureRedirect
+func (c *Client) doRaw(ctx context.Context, method, fullURL string, options []RequestOption) (resp *http.Response, meta *Response, captured bool, err error) {
+	req, err := c.newRequest(ctx, method, fullURL, options)
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	policy, policyKey := c.policyFor(req)
+	timeout := c.timeoutFor(req)
+	if policy.Timeout > 0 {
+		timeout = time.Duration(policy.Timeout)
+	}
+	if isUnbounded(req.Context()) {
+		timeout = 0
 	}
 
-	return parser(resp)
+	req, entry, err := c.journal.start(req)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	req, cancel := c.withDeadline(req, timeout)
+	release := func() {
+		cancel()
+		entry.done()
+	}
+	defer func() {
+		if err != nil || captured {
+			release()
+		}
+	}()
+
+	endSpans := c.startSpans(req)
+	c.hooks.request(req)
+	defer func() {
+		if err != nil {
+			c.hooks.error(req, meta, err)
+			if c.debugFor(req) {
+				logFailure(req, err, c.log)
+			}
+			if c.curlOnError {
+				c.logCurl(req, err)
+			}
+		}
+	}()
+
+	var interaction *Interaction
+	if c.sampleContract() {
+		interaction = newInteraction(req)
+	}
+
+	start := time.Now()
+	resp, meta, err = c.send(req, policy, policyKey, entry)
+	endSpans(meta, err)
+	status := 0
+	if err == nil {
+		status = resp.StatusCode
+	}
+	c.stats.observe(c.clock.Now(), statsKey(req), AttributionFromContext(req.Context()), time.Since(start), status)
+	if err != nil {
+		return nil, nil, false, withRequestID(req, err)
+	}
+	c.hooks.response(req, meta)
+
+	if c.debugFor(req) {
+		logResponse(resp, meta.Duration, c.log, c.redactor)
+	}
+
+	if interaction != nil {
+		interaction.setResponse(resp)
+		c.contractRecorder.Record(*interaction)
+	}
+
+	if c.har != nil {
+		if err := c.har.record(req, resp, meta, start, c.redactor); err != nil {
+			withError(c.log, err).Errorf("failed to record http exchange to HAR")
+		}
+	}
+
+	if a := c.archiverFor(req.URL.Path); a != nil {
+		if err := a.record(req, resp, meta, start, c.redactor); err != nil {
+			withError(c.log, err).Errorf("failed to archive http exchange")
+		}
+	}
+
+	if dst := redirectCapture(req.Context()); dst != nil && isRedirect(resp.StatusCode) {
+		defer c.closeBody(resp.Body)
+		return nil, meta, true, captureRedirect(dst, resp)
+	}
+
+	if err := c.validatorFor(req)(resp); err != nil {
+		c.closeBody(resp.Body)
+		return nil, meta, false, c.mapError(resp, err)
+	}
+
+	if c.strict != nil {
+		if err := c.strict.checkResponse(req, resp); err != nil {
+			c.closeBody(resp.Body)
+			return nil, meta, false, err
+		}
+	}
+
+	trackDownload(req, resp)
+	resp.Body = &ownedBody{body: resp.Body, client: c, release: release}
+	return resp, meta, false, nil
+}
+
+// ownedBody drains and closes the response body and then releases the
+// resources of the request
+type ownedBody struct {
+	body    io.ReadCloser
+	client  *Client
+	release func()
+	once    sync.Once
+}
+
+func (b *ownedBody) Read(p []byte) (int, error) {
+	return b.body.Read(p)
+}
+
+func (b *ownedBody) Close() error {
+	b.once.Do(func() {
+		b.client.closeBody(b.body)
+		b.release()
+	})
+	return nil
 }
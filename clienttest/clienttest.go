@@ -0,0 +1,213 @@
+// Package clienttest provides a deterministic harness for scenario tests of
+// clients built on go-http-client: a manually advanced clock driving the
+// circuit breakers, retry backoff waits and stats windows of the client, and
+// a scripted http doer which records the requests it receives.
+package clienttest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	cl "github.com/Traumeel/go-http-client"
+)
+
+// Clock is a fake clock which only moves when told to, pass it to the client
+// with cl.WithClock. Waits started with After end once the clock is moved
+// past them
+type Clock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []waiter
+}
+
+type waiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+func NewClock(now time.Time) *Clock {
+	return &Clock{now: now}
+}
+
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// After returns a channel receiving the clock time once it moved by d
+func (c *Clock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, waiter{at: c.now.Add(d), ch: ch})
+	return ch
+}
+
+// Waiting returns the number of waits started with After which are not over,
+// e.g. to find out that the client is backing off before advancing the clock
+func (c *Clock) Waiting() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.waiters)
+}
+
+// Advance moves the clock forward by d
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	c.fire()
+}
+
+// Set moves the clock to t
+func (c *Clock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = t
+	c.fire()
+}
+
+// fire end the waits which are over
+func (c *Clock) fire() {
+	pending := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.at.After(c.now) {
+			pending = append(pending, w)
+			continue
+		}
+		w.ch <- c.now
+	}
+	c.waiters = pending
+}
+
+// Responder produces the response for a single request
+type Responder func(*http.Request) (*http.Response, error)
+
+// Respond reply with the given status and body
+func Respond(status int, body string, header http.Header) Responder {
+	return func(req *http.Request) (*http.Response, error) {
+		if header == nil {
+			header = make(http.Header)
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+			StatusCode:    status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header.Clone(),
+			Body:          ioutil.NopCloser(bytes.NewBufferString(body)),
+			ContentLength: int64(len(body)),
+			Request:       req,
+		}, nil
+	}
+}
+
+// RespondJSON reply with the given status and v encoded as JSON
+func RespondJSON(status int, v interface{}) Responder {
+	return func(req *http.Request) (*http.Response, error) {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		header := http.Header{"Content-Type": {"application/json"}}
+		return Respond(status, string(data), header)(req)
+	}
+}
+
+// Fail reply with a transport error, e.g. to simulate an outage
+func Fail(err error) Responder {
+	return func(*http.Request) (*http.Response, error) {
+		return nil, err
+	}
+}
+
+// Doer is a scripted http doer, pass it to the client with cl.WithHttpClient.
+// Each request consumes the next queued responder, when the queue is empty
+// the fallback responder is used, or the request fails
+type Doer struct {
+	mu         sync.Mutex
+	responders []Responder
+	fallback   Responder
+	requests   []*http.Request
+}
+
+func (d *Doer) Do(req *http.Request) (*http.Response, error) {
+	d.mu.Lock()
+	d.requests = append(d.requests, req)
+	var next Responder
+	if len(d.responders) > 0 {
+		next, d.responders = d.responders[0], d.responders[1:]
+	} else {
+		next = d.fallback
+	}
+	d.mu.Unlock()
+
+	if next == nil {
+		return nil, fmt.Errorf("clienttest: unexpected request %v %v", req.Method, req.URL)
+	}
+	return next(req)
+}
+
+// Enqueue add responders for the next requests, in order
+func (d *Doer) Enqueue(r ...Responder) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.responders = append(d.responders, r...)
+}
+
+// SetFallback set the responder used when the queue is empty
+func (d *Doer) SetFallback(r Responder) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.fallback = r
+}
+
+// Requests returns the requests received so far
+func (d *Doer) Requests() []*http.Request {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]*http.Request(nil), d.requests...)
+}
+
+// Pending returns the number of queued responders not consumed yet
+func (d *Doer) Pending() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.responders)
+}
+
+// Harness wires a fake clock and a scripted doer together
+type Harness struct {
+	Clock *Clock
+	Doer  *Doer
+}
+
+func NewHarness() *Harness {
+	return &Harness{
+		Clock: NewClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)),
+		Doer:  &Doer{},
+	}
+}
+
+// Client returns a client sending its requests to the harness doer and
+// running on the harness clock
+func (h *Harness) Client(endpoint string, options ...cl.Option) *cl.Client {
+	return cl.NewClient(endpoint, append([]cl.Option{cl.WithHttpClient(h.Doer), cl.WithClock(h.Clock)}, options...)...)
+}
+
+// Step advances the clock by d and queues the responders for what follows
+func (h *Harness) Step(d time.Duration, responders ...Responder) {
+	h.Clock.Advance(d)
+	h.Doer.Enqueue(responders...)
+}
@@ -0,0 +1,32 @@
+package go_http_client
+
+import (
+	"time"
+)
+
+// Clock is the time source of the client: it opens and closes the circuit
+// breakers, times the retry backoff and reconnect waits and rotates the
+// Stats windows. Request latencies are always measured on the system clock
+type Clock interface {
+	Now() time.Time
+	// After waits for d to pass on the clock, as time.After
+	After(d time.Duration) <-chan time.Time
+}
+
+// WithClock replace the system clock, e.g. with the fake clock of the
+// clienttest package to step through breaker and backoff scenarios
+func WithClock(clock Clock) Option {
+	return func(c *Client) {
+		c.clock = clock
+	}
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
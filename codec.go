@@ -0,0 +1,49 @@
+package go_http_client
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Unmarshaller decodes a body read from r into v
+type Unmarshaller func(r io.Reader, v interface{}) error
+
+// Marshaller encodes v into a request body
+type Marshaller func(v interface{}) ([]byte, error)
+
+// DecodingParser decode the response body into dst with decode, calling the
+// ResponseMetadataSetter of dst like JsonParser. It is the building block of
+// the parsers of other formats
+func DecodingParser(dst interface{}, decode Unmarshaller) ResponseParser {
+	return func(resp *http.Response) (e error) {
+		if resp == nil || dst == nil || decode == nil {
+			return fmt.Errorf("DecodingParser function error: %v | %v", resp, dst)
+		}
+		if err := decode(resp.Body, dst); err != nil {
+			return err
+		}
+		setResponseMetadata(dst, resp)
+		return
+	}
+}
+
+// WithEncodedBodyOpt marshal v as the request body and set the Content-Type
+// to contentType
+func WithEncodedBodyOpt(v interface{}, contentType string, marshal Marshaller) RequestOption {
+	return func(req *http.Request) (e error) {
+		if req == nil || marshal == nil {
+			return fmt.Errorf("WithEncodedBodyOpt error: %v | %v", req, v)
+		}
+		data, err := marshal(v)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request body: %w", err)
+		}
+		if err := WithBodyOpt(bytes.NewReader(data))(req); err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", contentType)
+		return
+	}
+}
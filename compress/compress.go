@@ -0,0 +1,41 @@
+// Package compress adds brotli and zstd response decompression to the client.
+// It lives in its own module so the compression libraries are only pulled in
+// by the users who need them
+package compress
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	cl "github.com/Traumeel/go-http-client"
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// AcceptEncoding is the Accept-Encoding advertised by WithCompression
+const AcceptEncoding = "br, zstd, gzip"
+
+// Brotli decode a "br" encoded body
+func Brotli(r io.Reader) (io.ReadCloser, error) {
+	return ioutil.NopCloser(brotli.NewReader(r)), nil
+}
+
+// Zstd decode a "zstd" encoded body
+func Zstd(r io.Reader) (io.ReadCloser, error) {
+	dec, err := zstd.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	return dec.IOReadCloser(), nil
+}
+
+// WithCompression advertise AcceptEncoding on every request and decompress
+// brotli, zstd and gzip responses before the parsers see them
+func WithCompression() cl.Option {
+	return func(c *cl.Client) {
+		cl.WithContentDecoder("br", Brotli)(c)
+		cl.WithContentDecoder("zstd", Zstd)(c)
+		cl.WithDefaultHeaders(http.Header{"Accept-Encoding": {AcceptEncoding}})(c)
+	}
+}
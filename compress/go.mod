@@ -0,0 +1,9 @@
+module github.com/Traumeel/go-http-client/compress
+
+go 1.25
+
+require (
+	github.com/Traumeel/go-http-client v0.1.0
+	github.com/andybalholm/brotli v1.2.5
+	github.com/klauspost/compress v1.20.1
+)
@@ -0,0 +1,162 @@
+package go_http_client
+
+import (
+	"encoding/json"
+	"math/rand"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// ContractRecorder receives sampled interactions, e.g. to generate consumer
+// contracts for a contract-testing broker. Record is called synchronously
+// after the response was received, implementations should hand the
+// interaction off quickly
+type ContractRecorder interface {
+	Record(i Interaction)
+}
+
+// Interaction is a redacted request/response pair. It carries the shape of the
+// traffic only: query and header values are dropped and JSON bodies are
+// reduced to their schema, see BodySchema
+type Interaction struct {
+	Method          string
+	Path            string
+	QueryKeys       []string
+	RequestHeaders  []string
+	RequestType     string
+	RequestSchema   interface{}
+	Status          int
+	ResponseHeaders []string
+	ResponseType    string
+	ResponseSchema  interface{}
+	Attribution     Attribution
+}
+
+// WithContractRecorder stream a sample of the interactions to the recorder,
+// rate is the fraction of requests to record, between 0 and 1
+func WithContractRecorder(r ContractRecorder, rate float64) Option {
+	return func(c *Client) {
+		c.contractRecorder = r
+		c.contractSampleRate = rate
+	}
+}
+
+// contractBodyBytes is the most of a body read to infer its schema, larger
+// bodies are recorded without one
+const contractBodyBytes = 1 << 20
+
+func (c *Client) sampleContract() bool {
+	return c.contractRecorder != nil && rand.Float64() < c.contractSampleRate
+}
+
+func newInteraction(req *http.Request) *Interaction {
+	i := &Interaction{
+		Method:         req.Method,
+		Path:           req.URL.Path,
+		QueryKeys:      sortedKeys(req.URL.Query()),
+		RequestHeaders: sortedKeys(req.Header),
+		RequestType:    mediaType(req.Header),
+		Attribution:    AttributionFromContext(req.Context()),
+	}
+
+	// only JSON bodies have a schema, don't replay anything else
+	if req.GetBody != nil && isJSONMediaType(i.RequestType) {
+		if body, err := req.GetBody(); err == nil {
+			data, truncated, err := readBody(&body, contractBodyBytes)
+			body.Close()
+			if err == nil && !truncated {
+				i.RequestSchema = BodySchema(i.RequestType, data)
+			}
+		}
+	}
+
+	return i
+}
+
+// setResponse add the response to the interaction. Only the start of the body
+// is read and replaced, streaming responses aren't read at all, and a body
+// which can't be read whole is recorded without a schema. The parser gets
+// the read error, if any
+func (i *Interaction) setResponse(resp *http.Response) {
+	i.Status = resp.StatusCode
+	i.ResponseHeaders = sortedKeys(resp.Header)
+	i.ResponseType = mediaType(resp.Header)
+	// only JSON bodies have a schema, never read ahead of a stream
+	if !isJSONMediaType(i.ResponseType) || isStreamingMediaType(i.ResponseType) || resp.Body == nil || resp.Body == http.NoBody {
+		return
+	}
+
+	data, truncated, err := readBody(&resp.Body, contractBodyBytes)
+	if err == nil && !truncated {
+		i.ResponseSchema = BodySchema(i.ResponseType, data)
+	}
+}
+
+// BodySchema reduces a JSON body to its structure: object keys are kept,
+// scalar values are replaced with their type name ("string", "number",
+// "boolean", "null") and arrays with a single element schema. Non JSON bodies
+// have no schema
+func BodySchema(contentType string, body []byte) interface{} {
+	if len(body) == 0 || !isJSONMediaType(contentType) {
+		return nil
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return nil
+	}
+	return schemaOf(v)
+}
+
+func schemaOf(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, v := range t {
+			m[k] = schemaOf(v)
+		}
+		return m
+	case []interface{}:
+		if len(t) == 0 {
+			return []interface{}{}
+		}
+		return []interface{}{schemaOf(t[0])}
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	}
+	return "null"
+}
+
+func isJSONMediaType(mt string) bool {
+	return mt == MediaTypeJSON || strings.HasSuffix(mt, "+json")
+}
+
+// isStreamingMediaType reports whether bodies of type mt are open ended
+// streams, which can't be read ahead of the caller
+func isStreamingMediaType(mt string) bool {
+	switch mt {
+	case MediaTypeEventStream, "application/x-ndjson", "application/json-seq", "multipart/x-mixed-replace":
+		return true
+	}
+	return false
+}
+
+func mediaType(h http.Header) string {
+	mt, _, _ := mime.ParseMediaType(h.Get("Content-Type"))
+	return mt
+}
+
+func sortedKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,317 @@
+package go_http_client
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WithCookieJar send and store cookies through jar. Cookies are handled by
+// the client itself so it works with any http client, but cookies set on
+// redirects followed by the http client are only seen by the http client jar
+func WithCookieJar(jar http.CookieJar) Option {
+	return func(c *Client) {
+		c.cookieJar = jar
+	}
+}
+
+// WithPersistentCookies keep cookies in a file between runs, see
+// NewPersistentJar. If the file can't be loaded the error is logged and the
+// client runs without cookies, leaving the file untouched
+func WithPersistentCookies(path string, o *cookiejar.Options) Option {
+	return func(c *Client) {
+		jar, err := NewPersistentJar(path, o)
+		if err != nil {
+			c.logLater(func(log Logger) {
+				withError(log, err).Errorf("failed to load cookie jar %v", path)
+			})
+			return
+		}
+		c.cookieJar = jar
+	}
+}
+
+func (c *Client) addCookies(req *http.Request) {
+	if c.cookieJar == nil {
+		return
+	}
+	for _, cookie := range c.cookieJar.Cookies(req.URL) {
+		req.AddCookie(cookie)
+	}
+}
+
+func (c *Client) storeCookies(req *http.Request, resp *http.Response) {
+	if c.cookieJar == nil {
+		return
+	}
+	if cookies := resp.Cookies(); len(cookies) > 0 {
+		c.cookieJar.SetCookies(req.URL, cookies)
+	}
+}
+
+type storedCookie struct {
+	Name     string        `json:"name"`
+	Value    string        `json:"value"`
+	Domain   string        `json:"domain"`
+	Path     string        `json:"path"`
+	HostOnly bool          `json:"host_only,omitempty"`
+	Secure   bool          `json:"secure,omitempty"`
+	HttpOnly bool          `json:"http_only,omitempty"`
+	SameSite http.SameSite `json:"same_site,omitempty"`
+	Expires  time.Time     `json:"expires,omitempty"`
+}
+
+func (s *storedCookie) key() string {
+	return s.Domain + ";" + s.Path + ";" + s.Name
+}
+
+func (s *storedCookie) expired(now time.Time) bool {
+	return !s.Expires.IsZero() && !s.Expires.After(now)
+}
+
+// PersistentJar is a http.CookieJar saved to a file readable by the owner
+// only. Cookies are scoped to the host that set them, or to the domain given
+// in the cookie when the host belongs to it and it isn't a public suffix.
+// Session cookies are persisted as well so CLI sessions survive between
+// invocations
+type PersistentJar struct {
+	path string
+	psl  cookiejar.PublicSuffixList
+
+	mu      sync.Mutex
+	cookies map[string]*storedCookie
+	saveErr error
+}
+
+// NewPersistentJar load the jar from path, a missing file is an empty jar.
+// As with cookiejar.New, o may set a public suffix list such as
+// golang.org/x/net/publicsuffix.List so that a host can't set a cookie for a
+// public suffix like co.uk, shared with every site below it. Without a list
+// only top level domains are refused. o may be nil
+func NewPersistentJar(path string, o *cookiejar.Options) (*PersistentJar, error) {
+	j := &PersistentJar{
+		path:    path,
+		cookies: make(map[string]*storedCookie),
+	}
+	if o != nil {
+		j.psl = o.PublicSuffixList
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return j, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var cookies []*storedCookie
+	if err := json.Unmarshal(data, &cookies); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for _, s := range cookies {
+		if !s.expired(now) {
+			j.cookies[s.key()] = s
+		}
+	}
+	return j, nil
+}
+
+// Err returns the error of the last save, if it failed
+func (j *PersistentJar) Err() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.saveErr
+}
+
+func (j *PersistentJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	host := canonicalHost(u)
+	if host == "" {
+		return
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	now := time.Now()
+	changed := false
+	for _, cookie := range cookies {
+		s, ok := newStoredCookie(u, host, cookie, now, j.psl)
+		if !ok {
+			continue
+		}
+		if s.expired(now) {
+			if _, ok := j.cookies[s.key()]; ok {
+				delete(j.cookies, s.key())
+				changed = true
+			}
+			continue
+		}
+		j.cookies[s.key()] = s
+		changed = true
+	}
+
+	if changed {
+		j.saveErr = j.save()
+	}
+}
+
+func (j *PersistentJar) Cookies(u *url.URL) []*http.Cookie {
+	host := canonicalHost(u)
+	if host == "" {
+		return nil
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	now := time.Now()
+	matched := make([]*storedCookie, 0)
+	for _, s := range j.cookies {
+		if s.expired(now) || (s.Secure && u.Scheme != "https") {
+			continue
+		}
+		if !domainMatch(host, s) || !pathMatch(u.Path, s.Path) {
+			continue
+		}
+		matched = append(matched, s)
+	}
+
+	// more specific paths first as per RFC 6265 section 5.4
+	sort.Slice(matched, func(a, b int) bool {
+		return len(matched[a].Path) > len(matched[b].Path)
+	})
+
+	cookies := make([]*http.Cookie, 0, len(matched))
+	for _, s := range matched {
+		cookies = append(cookies, &http.Cookie{Name: s.Name, Value: s.Value})
+	}
+	return cookies
+}
+
+func (j *PersistentJar) save() error {
+	cookies := make([]*storedCookie, 0, len(j.cookies))
+	for _, s := range j.cookies {
+		cookies = append(cookies, s)
+	}
+	sort.Slice(cookies, func(a, b int) bool {
+		return cookies[a].key() < cookies[b].key()
+	})
+
+	data, err := json.MarshalIndent(cookies, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(j.path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	// write to a temporary file (created with 0600) and swap it in so a
+	// crash never leaves a truncated jar behind
+	tmp, err := ioutil.TempFile(dir, filepath.Base(j.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), j.path)
+}
+
+func newStoredCookie(u *url.URL, host string, cookie *http.Cookie, now time.Time, psl cookiejar.PublicSuffixList) (*storedCookie, bool) {
+	s := &storedCookie{
+		Name:     cookie.Name,
+		Value:    cookie.Value,
+		Path:     cookie.Path,
+		Secure:   cookie.Secure,
+		HttpOnly: cookie.HttpOnly,
+		SameSite: cookie.SameSite,
+	}
+
+	domain := strings.ToLower(strings.TrimPrefix(cookie.Domain, "."))
+	switch {
+	case domain != "" && psl != nil && psl.PublicSuffix(domain) == domain:
+		// a public suffix is shared by unrelated sites, only the host
+		// itself may set a cookie for it, as a host cookie
+		if domain != host {
+			return nil, false
+		}
+		s.Domain, s.HostOnly = host, true
+	case domain == "" || domain == host:
+		s.Domain, s.HostOnly = host, cookie.Domain == ""
+	case net.ParseIP(host) != nil || !strings.Contains(domain, "."):
+		// IP hosts can't set domain cookies and a bare top level domain
+		// would leak the cookie to every site below it
+		return nil, false
+	case strings.HasSuffix(host, "."+domain):
+		s.Domain = domain
+	default:
+		return nil, false
+	}
+
+	if s.Path == "" || s.Path[0] != '/' {
+		s.Path = defaultCookiePath(u.Path)
+	}
+
+	switch {
+	case cookie.MaxAge < 0:
+		s.Expires = now
+	case cookie.MaxAge > 0:
+		s.Expires = now.Add(time.Duration(cookie.MaxAge) * time.Second)
+	case !cookie.Expires.IsZero():
+		s.Expires = cookie.Expires
+	}
+
+	return s, true
+}
+
+func canonicalHost(u *url.URL) string {
+	return strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+}
+
+func domainMatch(host string, s *storedCookie) bool {
+	if host == s.Domain {
+		return true
+	}
+	return !s.HostOnly && strings.HasSuffix(host, "."+s.Domain)
+}
+
+func pathMatch(requestPath, cookiePath string) bool {
+	if requestPath == "" {
+		requestPath = "/"
+	}
+	if requestPath == cookiePath {
+		return true
+	}
+	if !strings.HasPrefix(requestPath, cookiePath) {
+		return false
+	}
+	return strings.HasSuffix(cookiePath, "/") || requestPath[len(cookiePath)] == '/'
+}
+
+func defaultCookiePath(requestPath string) string {
+	i := strings.LastIndex(requestPath, "/")
+	if i <= 0 {
+		return "/"
+	}
+	return requestPath[:i]
+}
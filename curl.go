@@ -0,0 +1,127 @@
+package go_http_client
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// CurlString returns a curl command equivalent to req, with the secrets
+// redacted as in the debug dumps. The body is read through GetBody when set,
+// otherwise it is read and replaced. Binary bodies are replaced with
+// "--data-binary @body", to point at a local copy, and so are bodies over
+// 64 KiB
+func CurlString(req *http.Request) (string, error) {
+	return curlCommand(req, redactorOf(req.Context()), true)
+}
+
+// WithCurlOnError log a curl command reproducing each failed request, with the
+// secrets redacted. Bodies which can't be read again are left out
+func WithCurlOnError(b bool) Option {
+	return func(c *Client) {
+		c.curlOnError = b
+	}
+}
+
+func (c *Client) logCurl(req *http.Request, err error) {
+	cmd, cerr := curlCommand(req, c.redactor, false)
+	if cerr != nil {
+		withError(c.log, cerr).Errorf("failed to build curl command")
+		return
+	}
+	withError(c.log, err).WithFields(logFields(req.Context())).WithFields(Fields{
+		"method": req.Method,
+		"url":    RedactedURL(req),
+	}).Warnf("http request failed, reproduce with: %s", cmd)
+}
+
+// curlCommand build the command, the body is only read from req.Body when
+// consume is set since it is gone once the request was sent
+func curlCommand(req *http.Request, r *redactor, consume bool) (string, error) {
+	// --globoff so curl doesn't read the brackets of masked query values
+	// and IPv6 hosts as URL ranges
+	args := []string{"curl", "--globoff"}
+	if req.Method != "" && req.Method != http.MethodGet {
+		args = append(args, "-X", shellQuote(req.Method))
+	}
+	args = append(args, shellQuote(r.url(req.URL).String()))
+
+	if req.Host != "" && req.Host != req.URL.Host {
+		args = append(args, "-H", shellQuote("Host: "+req.Host))
+	}
+	header := r.header(req.Header)
+	keys := make([]string, 0, len(header))
+	for k := range header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range header[k] {
+			args = append(args, "-H", shellQuote(k+": "+v))
+		}
+	}
+
+	data, err := curlData(req, r, consume)
+	if err != nil {
+		return "", err
+	}
+	if data != "" {
+		args = append(args, "--data-binary", data)
+	}
+	return strings.Join(args, " "), nil
+}
+
+// maxCurlBodyBytes is the largest body written in a curl command, larger
+// ones are replaced with @body like binary ones
+const maxCurlBodyBytes = 64 << 10
+
+// curlData returns the --data-binary argument for the body of req, empty when
+// there is none. Binary and large bodies are recognized by their headers
+// where possible so they aren't read, and at most maxCurlBodyBytes is read
+func curlData(req *http.Request, r *redactor, consume bool) (string, error) {
+	if !hasBody(req) || (req.GetBody == nil && !consume) {
+		return "", nil
+	}
+	mt := mediaType(req.Header)
+	if (mt != "" && !isTextMediaType(mt)) || req.ContentLength > maxCurlBodyBytes {
+		return "@body", nil
+	}
+
+	body, truncated, err := curlBody(req)
+	if err != nil {
+		return "", err
+	}
+	if len(body) == 0 {
+		return "", nil
+	}
+	if mt == "" {
+		mt = mediaType(http.Header{"Content-Type": {http.DetectContentType(body)}})
+	}
+	switch {
+	case truncated, !isTextMediaType(mt):
+		return "@body", nil
+	case len(r.jsonRules) > 0 && isJSONMediaType(mt):
+		return shellQuote(string(r.redactJSONBody(body))), nil
+	}
+	return shellQuote(string(body)), nil
+}
+
+// curlBody read up to maxCurlBodyBytes of the body, through GetBody when set
+// or else from req.Body, which is replaced
+func curlBody(req *http.Request) ([]byte, bool, error) {
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, false, err
+		}
+		data, truncated, err := readBody(&body, maxCurlBodyBytes)
+		body.Close()
+		return data, truncated, err
+	}
+	return readBody(&req.Body, maxCurlBodyBytes)
+}
+
+// shellQuote quote s for a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,24 @@
+package go_http_client
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestCurlStringGlobOff(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "https://api.example.com/items?api_key=secret&page=2", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd, err := CurlString(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(cmd, "curl --globoff ") {
+		t.Errorf("cmd = %v, want globbing off", cmd)
+	}
+	if strings.Contains(cmd, "secret") {
+		t.Errorf("cmd = %v, masked value leaked", cmd)
+	}
+}
@@ -0,0 +1,86 @@
+// Package datadog traces the requests of the client with Datadog APM. It
+// lives in its own module so dd-trace-go is only pulled in by the users who
+// need it
+package datadog
+
+import (
+	"net/http"
+	"strconv"
+
+	cl "github.com/Traumeel/go-http-client"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+type Option func(*config)
+
+type config struct {
+	service string
+}
+
+// WithServiceName set the service name of the spans, the service of the
+// tracer by default
+func WithServiceName(name string) Option {
+	return func(c *config) {
+		c.service = name
+	}
+}
+
+// WithDatadog create an http.request span per request, a child of the span in
+// the request context, and inject the distributed tracing headers. The span
+// starts once the request options are applied, so it carries the request tags
+// and attribution, covers the retries and finishes when the response headers
+// were received or the request failed. 5xx responses mark the span as failed
+func WithDatadog(options ...Option) cl.Option {
+	cfg := config{}
+	for _, opt := range options {
+		opt(&cfg)
+	}
+
+	return cl.WithSpanStarter(func(req *http.Request) func(*cl.Response, error) {
+		resource := req.Method + " " + req.URL.Path
+		if op := cl.OperationFromContext(req.Context()); op != "" {
+			resource = req.Method + " " + op
+		}
+		opts := []ddtrace.StartSpanOption{
+			tracer.SpanType(ext.SpanTypeHTTP),
+			tracer.ResourceName(resource),
+			tracer.Tag(ext.SpanKind, ext.SpanKindClient),
+			tracer.Tag(ext.HTTPMethod, req.Method),
+			tracer.Tag(ext.HTTPURL, cl.RedactedURL(req)),
+			tracer.Tag(ext.NetworkDestinationName, req.URL.Hostname()),
+		}
+		if cfg.service != "" {
+			opts = append(opts, tracer.ServiceName(cfg.service))
+		}
+
+		for k, v := range cl.TagsFromContext(req.Context()) {
+			opts = append(opts, tracer.Tag(k, v))
+		}
+
+		span, ctx := tracer.StartSpanFromContext(req.Context(), "http.request", opts...)
+		if err := tracer.Inject(span.Context(), tracer.HTTPHeadersCarrier(req.Header)); err != nil {
+			// the span is still worth having without distributed tracing
+			span.SetTag("inject.error", err.Error())
+		}
+		*req = *req.WithContext(ctx)
+
+		return func(resp *cl.Response, err error) {
+			finishSpan(span, resp, err)
+		}
+	})
+}
+
+func finishSpan(span ddtrace.Span, resp *cl.Response, err error) {
+	if err != nil {
+		span.Finish(tracer.WithError(err))
+		return
+	}
+	span.SetTag(ext.HTTPCode, strconv.Itoa(resp.StatusCode))
+	if resp.StatusCode >= 500 {
+		span.SetTag(ext.Error, true)
+		span.SetTag(ext.ErrorMsg, resp.Status)
+	}
+	span.Finish()
+}
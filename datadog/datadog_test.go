@@ -0,0 +1,68 @@
+package datadog
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	cl "github.com/Traumeel/go-http-client"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+)
+
+func TestSpanAfterRequestOptions(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	var parentID string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parentID = r.Header.Get("X-Datadog-Parent-Id")
+	}))
+	defer srv.Close()
+
+	c := cl.NewClient(srv.URL, WithDatadog())
+	ctx := cl.ContextWithAttribution(context.Background(), cl.Attribution{CostCenter: "cc-42"})
+	err := c.DoRequestNoBody(ctx, http.MethodGet, "/users/42",
+		cl.WithTagOpt("tenant", "acme"),
+		cl.WithHeadersReplaceOpt(http.Header{"Accept": {"*/*"}}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spans := mt.FinishedSpans()
+	if len(spans) != 1 {
+		t.Fatalf("finished %v spans, want 1", len(spans))
+	}
+	if spans[0].Tag("tenant") != "acme" || spans[0].Tag(cl.CostCenterTag) != "cc-42" {
+		t.Errorf("tags = %v", spans[0].Tags())
+	}
+	if parentID == "" {
+		t.Error("tracing headers not sent")
+	}
+}
+
+func TestSpanFinishedOnEveryPath(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	c := cl.NewClient(srv.URL, WithDatadog())
+
+	failing := func(*http.Request) error { return errors.New("boom") }
+	if err := c.DoRequestNoBody(context.Background(), http.MethodGet, "/", failing); err == nil {
+		t.Fatal("failing option didn't fail the request")
+	}
+	if err := c.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.DoRequestNoBody(context.Background(), http.MethodGet, "/"); !errors.Is(err, cl.ErrClientClosed) {
+		t.Fatalf("err = %v, want ErrClientClosed", err)
+	}
+
+	if open := mt.OpenSpans(); len(open) != 0 {
+		t.Fatalf("%v spans left open", len(open))
+	}
+}
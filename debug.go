@@ -0,0 +1,177 @@
+package go_http_client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type debugKey struct{}
+
+// ContextWithDebug enable or disable the debug dumps of the requests made
+// with ctx, whatever the client setting
+func ContextWithDebug(ctx context.Context, on bool) context.Context {
+	return context.WithValue(ctx, debugKey{}, on)
+}
+
+// WithDebugOpt enable or disable the debug dumps for this request only, so a
+// single call can be traced without debugging the whole client
+func WithDebugOpt(on bool) RequestOption {
+	return func(req *http.Request) (e error) {
+		*req = *req.WithContext(ContextWithDebug(req.Context(), on))
+		return
+	}
+}
+
+// WithDebugSampling dump only a fraction of the requests in debug mode, rate
+// is between 0 and 1. The dumps of a request and its retries are all kept or
+// all dropped, and requests debugged with WithDebugOpt or ContextWithDebug
+// are always dumped. It keeps request logging affordable in high-QPS services
+func WithDebugSampling(rate float64) Option {
+	return func(c *Client) {
+		c.debugSampleRate = rate
+	}
+}
+
+type debugSampledKey struct{}
+
+// sampleDebug draw whether the request made with ctx is dumped
+func (c *Client) sampleDebug(ctx context.Context) context.Context {
+	if !c.debug || c.debugSampleRate >= 1 {
+		return ctx
+	}
+	return context.WithValue(ctx, debugSampledKey{}, rand.Float64() < c.debugSampleRate)
+}
+
+// debugFor reports whether the exchange of req is dumped, the request
+// setting wins over the client one and its sampling
+func (c *Client) debugFor(req *http.Request) bool {
+	if on, ok := req.Context().Value(debugKey{}).(bool); ok {
+		return on
+	}
+	if sampled, ok := req.Context().Value(debugSampledKey{}).(bool); ok {
+		return c.debug && sampled
+	}
+	return c.debug
+}
+
+// debugBodyTruncated marks a dumped body cut at the debug body limit
+const debugBodyTruncated = "... [truncated]"
+
+// DefaultDebugBodyBytes is the most of a body dumped when no debug body limit
+// is set, so debug mode never buffers a whole download
+const DefaultDebugBodyBytes = 1 << 20
+
+// WithDebugBodyLimit dump at most n bytes of the request and response bodies
+// in debug mode and skip the bodies of binary content types, so debugging
+// doesn't flood the logs nor copy large payloads. Only the dumped part of a
+// body is buffered. 0 dumps binary bodies too, up to DefaultDebugBodyBytes
+func WithDebugBodyLimit(n int64) Option {
+	return func(c *Client) {
+		c.redactor.bodyLimit = n
+	}
+}
+
+// isTextMediaType reports whether bodies of type mt are worth dumping
+func isTextMediaType(mt string) bool {
+	switch {
+	case strings.HasPrefix(mt, "text/"), isJSONMediaType(mt), strings.HasSuffix(mt, "+xml"):
+		return true
+	}
+	switch mt {
+	case "application/xml", "application/x-www-form-urlencoded", "application/javascript",
+		"application/graphql", "application/yaml", "application/x-yaml":
+		return true
+	}
+	return false
+}
+
+// binaryBody is dumped instead of a binary body, size is -1 when unknown
+func binaryBody(mt string, size int64) []byte {
+	if size < 0 {
+		return []byte(fmt.Sprintf("[%v body omitted]", mt))
+	}
+	return []byte(fmt.Sprintf("[%v body of %v bytes omitted]", mt, size))
+}
+
+// streamingBody is dumped instead of a streaming body, which is left for the
+// caller to read
+func streamingBody(mt string) []byte {
+	return []byte(fmt.Sprintf("[%v stream not dumped]", mt))
+}
+
+// Dump is a request or response as dumped in debug mode, with the secrets
+// redacted and the body cut as set with WithDebugBodyLimit
+type Dump struct {
+	Method string
+	URL    string
+	// Status is 0 in request dumps
+	Status int
+	Header http.Header
+	Body   []byte
+	// Duration is the time until the response headers were received, 0 in
+	// request dumps or when unknown
+	Duration  time.Duration
+	Tags      Tags
+	RequestID string
+}
+
+// DumpFormatter renders the debug dumps as a log message and extra fields,
+// in place of the httputil.DumpRequestOut and httputil.DumpResponse output
+type DumpFormatter interface {
+	FormatRequest(d Dump) (string, Fields)
+	FormatResponse(d Dump) (string, Fields)
+}
+
+// WithDumpFormatter render the debug dumps with f, e.g. JSONDumpFormatter for
+// log aggregation systems
+func WithDumpFormatter(f DumpFormatter) Option {
+	return func(c *Client) {
+		c.redactor.formatter = f
+	}
+}
+
+// JSONDumpFormatter renders each dump as a single line JSON object
+type JSONDumpFormatter struct{}
+
+type jsonDump struct {
+	Type       string              `json:"type"`
+	Method     string              `json:"method,omitempty"`
+	URL        string              `json:"url,omitempty"`
+	Status     int                 `json:"status,omitempty"`
+	DurationMs float64             `json:"duration_ms,omitempty"`
+	Header     map[string][]string `json:"header,omitempty"`
+	Body       string              `json:"body,omitempty"`
+	Tags       Tags                `json:"tags,omitempty"`
+	RequestID  string              `json:"request_id,omitempty"`
+}
+
+func (JSONDumpFormatter) FormatRequest(d Dump) (string, Fields) {
+	return formatJSONDump("request", d), nil
+}
+
+func (JSONDumpFormatter) FormatResponse(d Dump) (string, Fields) {
+	return formatJSONDump("response", d), nil
+}
+
+func formatJSONDump(kind string, d Dump) string {
+	line, err := json.Marshal(jsonDump{
+		Type:       kind,
+		Method:     d.Method,
+		URL:        d.URL,
+		Status:     d.Status,
+		DurationMs: float64(d.Duration) / float64(time.Millisecond),
+		Header:     d.Header,
+		Body:       string(d.Body),
+		Tags:       d.Tags,
+		RequestID:  d.RequestID,
+	})
+	if err != nil {
+		return fmt.Sprintf(`{"type":%q,"error":%q}`, kind, err)
+	}
+	return string(line)
+}
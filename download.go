@@ -0,0 +1,328 @@
+package go_http_client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Download stream the response body of a GET request to w and return the
+// number of bytes written. Unlike DownloadFile it goes through the response
+// validator and the client policies
+func (c *Client) Download(ctx context.Context, path string, w io.Writer, options ...RequestOption) (int64, error) {
+	var n int64
+	err := c.DoRequestStream(ctx, http.MethodGet, path, func(r io.Reader) (err error) {
+		n, err = io.Copy(w, r)
+		return
+	}, options...)
+	return n, err
+}
+
+// DownloadToFile download to filename. The body is written to a temporary
+// file next to it which replaces filename only once the download completed,
+// a failed download leaves no partial file behind
+func (c *Client) DownloadToFile(ctx context.Context, path, filename string, options ...RequestOption) (int64, error) {
+	tmp, err := ioutil.TempFile(filepath.Dir(filename), "."+filepath.Base(filename)+".part")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+
+	n, err := c.Download(ctx, path, tmp, options...)
+	if err != nil {
+		tmp.Close()
+		return n, err
+	}
+	if err := tmp.Close(); err != nil {
+		return n, err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return n, err
+	}
+	return n, os.Rename(tmp.Name(), filename)
+}
+
+// resumeState is the validator of a partial download, saved next to it
+type resumeState struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// validator returns the If-Range value, weak ETags can't be used for ranges
+func (s resumeState) validator() string {
+	if s.ETag != "" && !strings.HasPrefix(s.ETag, "W/") {
+		return s.ETag
+	}
+	return s.LastModified
+}
+
+// ResumeDownload download to filename like DownloadToFile but keep the
+// partial file (filename.part) when the download fails, so a later call picks
+// up where it stopped. The remaining bytes are requested with Range and
+// If-Range, the server sends the whole object again when it changed since the
+// partial download started or doesn't support ranges. A 416 response whose
+// Content-Range size is the size of the partial file completes it. It returns
+// the size of the downloaded file
+func (c *Client) ResumeDownload(ctx context.Context, path, filename string, options ...RequestOption) (int64, error) {
+	part, statePath := filename+".part", filename+".part.json"
+
+	n, err := c.resumeDownload(ctx, path, part, statePath, options)
+	var statusErr StatusCodeError
+	if errors.As(err, &statusErr) && statusErr.Code == http.StatusRequestedRangeNotSatisfiable {
+		// the partial file doesn't match the object anymore, start over
+		os.Remove(part)
+		os.Remove(statePath)
+		n, err = c.resumeDownload(ctx, path, part, statePath, options)
+	}
+	if err != nil {
+		return n, err
+	}
+
+	if err := os.Rename(part, filename); err != nil {
+		return n, err
+	}
+	os.Remove(statePath)
+	return n, nil
+}
+
+func (c *Client) resumeDownload(ctx context.Context, path, part, statePath string, options []RequestOption) (int64, error) {
+	f, err := os.OpenFile(part, os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	offset := info.Size()
+
+	state := resumeState{}
+	if data, err := ioutil.ReadFile(statePath); err == nil {
+		json.Unmarshal(data, &state)
+	}
+	if offset > 0 && state.validator() != "" {
+		options = append(options, WithHeadersOpt(http.Header{
+			"Range":    {fmt.Sprintf("bytes=%d-", offset)},
+			"If-Range": {state.validator()},
+		}))
+	} else {
+		offset = 0
+	}
+
+	resp, err := c.DoRequestRaw(asTransfer(ctx), http.MethodGet, path, options...)
+	var statusErr StatusCodeError
+	if offset > 0 && errors.As(err, &statusErr) && statusErr.Code == http.StatusRequestedRangeNotSatisfiable &&
+		contentRangeSize(statusErr.Header.Get("Content-Range")) == offset {
+		// the partial file is already complete
+		return offset, f.Close()
+	}
+	if err != nil {
+		return offset, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusPartialContent || contentRangeStart(resp.Header.Get("Content-Range")) != offset {
+		offset = 0
+	}
+	if err := f.Truncate(offset); err != nil {
+		return offset, err
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return offset, err
+	}
+
+	state = resumeState{ETag: resp.Header.Get("ETag"), LastModified: resp.Header.Get("Last-Modified")}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return offset, err
+	}
+	if err := ioutil.WriteFile(statePath, data, 0644); err != nil {
+		return offset, err
+	}
+
+	n, err := io.Copy(f, resp.Body)
+	if err != nil {
+		return offset + n, err
+	}
+	return offset + n, f.Close()
+}
+
+// contentRangeSize returns the complete length of a "bytes */size" or
+// "bytes first-last/size" Content-Range, -1 when it can't be parsed or is
+// unknown
+func contentRangeSize(contentRange string) int64 {
+	if !strings.HasPrefix(contentRange, "bytes ") {
+		return -1
+	}
+	i := strings.LastIndexByte(contentRange, '/')
+	if i < 0 {
+		return -1
+	}
+	n, err := strconv.ParseInt(contentRange[i+1:], 10, 64)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// contentRangeStart returns the first byte of a "bytes first-last/size"
+// Content-Range, -1 when it can't be parsed
+func contentRangeStart(contentRange string) int64 {
+	spec := strings.TrimPrefix(contentRange, "bytes ")
+	if spec == contentRange {
+		return -1
+	}
+	first := strings.SplitN(spec, "-", 2)[0]
+	n, err := strconv.ParseInt(first, 10, 64)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// DownloadParallel download the object at path in segments concurrent range
+// requests written to w at their offsets. It falls back to a single request
+// when the server doesn't advertise Accept-Ranges: bytes or the size is
+// unknown. Segments are pinned to the ETag of the object (If-Match) so a
+// change during the download fails it instead of mixing versions. It returns
+// the size of the object
+func (c *Client) DownloadParallel(ctx context.Context, path string, w io.WriterAt, segments int, options ...RequestOption) (int64, error) {
+	head, err := c.DoRequestMeta(ctx, http.MethodHead, path, NoBodyParser(nil), options...)
+	if err != nil {
+		return 0, err
+	}
+
+	size, err := strconv.ParseInt(head.Header.Get("Content-Length"), 10, 64)
+	if err != nil || size <= 0 || segments <= 1 || head.Header.Get("Accept-Ranges") != "bytes" {
+		return c.Download(ctx, path, &offsetWriter{w: w}, options...)
+	}
+
+	var pin http.Header
+	if etag := head.Header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		pin = http.Header{"If-Match": {etag}}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	segmentSize := (size + int64(segments) - 1) / int64(segments)
+	errs := make([]error, 0)
+	mu := sync.Mutex{}
+	wg := sync.WaitGroup{}
+	for first := int64(0); first < size; first += segmentSize {
+		last := first + segmentSize - 1
+		if last >= size {
+			last = size - 1
+		}
+
+		wg.Add(1)
+		go func(first, last int64) {
+			defer wg.Done()
+			if err := c.downloadSegment(ctx, path, w, first, last, pin, options); err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+				cancel()
+			}
+		}(first, last)
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return 0, errs[0]
+	}
+	return size, nil
+}
+
+func (c *Client) downloadSegment(ctx context.Context, path string, w io.WriterAt, first, last int64, pin http.Header, options []RequestOption) error {
+	opts := append([]RequestOption{}, options...)
+	opts = append(opts, WithHeadersOpt(http.Header{"Range": {fmt.Sprintf("bytes=%d-%d", first, last)}}))
+	if pin != nil {
+		opts = append(opts, WithHeadersOpt(pin))
+	}
+
+	resp, err := c.DoRequestRaw(asTransfer(ctx), http.MethodGet, path, opts...)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusPartialContent || contentRangeStart(resp.Header.Get("Content-Range")) != first {
+		return fmt.Errorf("range %v-%v not honoured: %v", first, last, resp.Status)
+	}
+
+	n, err := io.Copy(&offsetWriter{w: w, offset: first}, io.LimitReader(resp.Body, last-first+1))
+	if err != nil {
+		return err
+	}
+	if n != last-first+1 {
+		return fmt.Errorf("range %v-%v: %w", first, last, io.ErrUnexpectedEOF)
+	}
+	return nil
+}
+
+// DownloadParallelToFile download to filename with DownloadParallel, through
+// a temporary file as DownloadToFile
+func (c *Client) DownloadParallelToFile(ctx context.Context, path, filename string, segments int, options ...RequestOption) (int64, error) {
+	tmp, err := ioutil.TempFile(filepath.Dir(filename), "."+filepath.Base(filename)+".part")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+
+	n, err := c.DownloadParallel(ctx, path, tmp, segments, options...)
+	if err != nil {
+		tmp.Close()
+		return n, err
+	}
+	if err := tmp.Close(); err != nil {
+		return n, err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return n, err
+	}
+	return n, os.Rename(tmp.Name(), filename)
+}
+
+// offsetWriter writes sequentially to w from offset
+type offsetWriter struct {
+	w      io.WriterAt
+	offset int64
+}
+
+func (o *offsetWriter) Write(p []byte) (int, error) {
+	n, err := o.w.WriteAt(p, o.offset)
+	o.offset += int64(n)
+	return n, err
+}
+
+// ContentDispositionFilename returns the file name the server suggests in the
+// Content-Disposition header (RFC 6266), preferring the UTF-8 filename* over
+// the plain filename. Directories are stripped so the name is safe to join
+// to a download directory. It returns "" when there is no usable name
+func ContentDispositionFilename(h http.Header) string {
+	_, params, err := mime.ParseMediaType(h.Get("Content-Disposition"))
+	if err != nil {
+		return ""
+	}
+
+	// mime decodes filename* into filename, dropping unsupported charsets
+	name := params["filename"]
+	name = name[strings.LastIndexAny(name, `/\`)+1:]
+	if name == "." || name == ".." {
+		return ""
+	}
+	return name
+}
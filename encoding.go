@@ -0,0 +1,202 @@
+package go_http_client
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// EncodingMode controls what happens to compressed responses when the
+// request set Accept-Encoding itself. net/http only decompresses
+// transparently when it added the header, so such bodies arrive compressed
+type EncodingMode int
+
+const (
+	// EncodingDecompress decompress gzip and deflate bodies in the client, as
+	// well as the encodings added with WithContentDecoder
+	EncodingDecompress EncodingMode = iota
+	// EncodingWarn leave the body compressed and log a warning
+	EncodingWarn
+	// EncodingIgnore leave the body compressed, e.g. to store it as is
+	EncodingIgnore
+)
+
+// WithManualEncoding set how compressed responses to requests with a manually
+// set Accept-Encoding are handled, EncodingDecompress by default
+func WithManualEncoding(mode EncodingMode) Option {
+	return func(c *Client) {
+		c.encodingMode = mode
+	}
+}
+
+// Decoder wraps a body compressed with a content encoding into its decoded
+// stream
+type Decoder func(io.Reader) (io.ReadCloser, error)
+
+// WithContentDecoder decode response bodies with the given Content-Encoding,
+// e.g. "br", on top of the built-in gzip and deflate. Like those it only
+// applies when Accept-Encoding is set on the request, for example with
+// WithDefaultHeaders
+func WithContentDecoder(encoding string, dec Decoder) Option {
+	return func(c *Client) {
+		if c.decoders == nil {
+			c.decoders = make(map[string]Decoder)
+		}
+		c.decoders[strings.ToLower(encoding)] = dec
+	}
+}
+
+var decoders = map[string]Decoder{
+	"gzip": func(r io.Reader) (io.ReadCloser, error) {
+		return gzip.NewReader(r)
+	},
+	"deflate": func(r io.Reader) (io.ReadCloser, error) {
+		// deflate is meant to be zlib wrapped but some servers send raw
+		// deflate streams, zlib streams start with 0x78
+		br := bufio.NewReader(r)
+		if b, err := br.Peek(1); err == nil && b[0] == 0x78 {
+			return zlib.NewReader(br)
+		}
+		return flate.NewReader(br), nil
+	},
+}
+
+type decodedBody struct {
+	io.ReadCloser
+	raw io.Closer
+}
+
+func (b decodedBody) Close() error {
+	b.ReadCloser.Close()
+	return b.raw.Close()
+}
+
+func (c *Client) decodeBody(req *http.Request, resp *http.Response) error {
+	if resp.Uncompressed || req.Header.Get("Accept-Encoding") == "" || req.Method == http.MethodHead {
+		return nil
+	}
+
+	encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+	dec, ok := c.decoders[encoding]
+	if !ok {
+		dec, ok = decoders[encoding]
+	}
+	if !ok {
+		return nil
+	}
+
+	switch c.encodingMode {
+	case EncodingIgnore:
+		return nil
+	case EncodingWarn:
+		c.log.Warnf("response from %v is %v encoded because Accept-Encoding was set on the request", req.URL, encoding)
+		return nil
+	}
+
+	body, err := dec(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	resp.Body = decodedBody{ReadCloser: body, raw: resp.Body}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return nil
+}
+
+type gzipRequestKey struct{}
+
+// WithGzipRequestOpt gzip the request body and set Content-Encoding: gzip.
+// The body is compressed once all the options were applied, whatever their
+// order, and kept in memory so retries can resend it
+func WithGzipRequestOpt() RequestOption {
+	return func(req *http.Request) (e error) {
+		*req = *req.WithContext(context.WithValue(req.Context(), gzipRequestKey{}, true))
+		return
+	}
+}
+
+func gzipRequest(req *http.Request) error {
+	if on, _ := req.Context().Value(gzipRequestKey{}).(bool); !on || !hasBody(req) {
+		return nil
+	}
+	if encoding := req.Header.Get("Content-Encoding"); encoding != "" {
+		return fmt.Errorf("WithGzipRequestOpt error: body is already %v encoded", encoding)
+	}
+
+	data, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return fmt.Errorf("failed to read request body: %w", err)
+	}
+
+	buf := &bytes.Buffer{}
+	zw := gzip.NewWriter(buf)
+	if _, err := zw.Write(data); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	compressed := buf.Bytes()
+	req.Body = ioutil.NopCloser(bytes.NewReader(compressed))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(compressed)), nil
+	}
+	req.ContentLength = int64(len(compressed))
+	req.Header.Set("Content-Encoding", "gzip")
+	return nil
+}
+
+// CharsetDecoder returns r transcoded from charset to UTF-8, the charset is
+// lower cased and never utf-8 or us-ascii
+type CharsetDecoder func(charset string, r io.Reader) (io.Reader, error)
+
+// WithCharsetDecoder transcode text responses declaring a charset= other than
+// UTF-8 in their Content-Type before the parsers run, the Content-Type is
+// then rewritten to charset=utf-8. The charset module provides a decoder for
+// the encodings known to golang.org/x/text
+func WithCharsetDecoder(dec CharsetDecoder) Option {
+	return func(c *Client) {
+		c.charsetDecoder = dec
+	}
+}
+
+func (c *Client) decodeCharset(resp *http.Response) error {
+	if c.charsetDecoder == nil {
+		return nil
+	}
+
+	mt, params, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil {
+		return nil
+	}
+	charset := strings.ToLower(params["charset"])
+	if charset == "" || charset == "utf-8" || charset == "utf8" || charset == "us-ascii" {
+		return nil
+	}
+
+	r, err := c.charsetDecoder(charset, resp.Body)
+	if err != nil {
+		return err
+	}
+
+	params["charset"] = "utf-8"
+	resp.Body = decodedBody{ReadCloser: ioutil.NopCloser(r), raw: resp.Body}
+	resp.Header.Set("Content-Type", mime.FormatMediaType(mt, params))
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	return nil
+}
@@ -0,0 +1,253 @@
+package go_http_client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+)
+
+// Errors matched by StatusCodeError with errors.Is according to its status
+var (
+	ErrBadRequest          = errors.New("bad request")
+	ErrUnauthorized        = errors.New("unauthorized")
+	ErrForbidden           = errors.New("forbidden")
+	ErrNotFound            = errors.New("not found")
+	ErrConflict            = errors.New("conflict")
+	ErrPreconditionFailed  = errors.New("precondition failed")
+	ErrTooManyRequests     = errors.New("too many requests")
+	ErrInternalServerError = errors.New("internal server error")
+	ErrServiceUnavailable  = errors.New("service unavailable")
+	// ErrClientError matches all 4xx statuses
+	ErrClientError = errors.New("client error")
+	// ErrServerError matches all 5xx statuses
+	ErrServerError = errors.New("server error")
+)
+
+var statusErrors = map[int]error{
+	http.StatusBadRequest:          ErrBadRequest,
+	http.StatusUnauthorized:        ErrUnauthorized,
+	http.StatusForbidden:           ErrForbidden,
+	http.StatusNotFound:            ErrNotFound,
+	http.StatusConflict:            ErrConflict,
+	http.StatusPreconditionFailed:  ErrPreconditionFailed,
+	http.StatusTooManyRequests:     ErrTooManyRequests,
+	http.StatusInternalServerError: ErrInternalServerError,
+	http.StatusServiceUnavailable:  ErrServiceUnavailable,
+}
+
+// Is match the sentinel error of the status
+func (t StatusCodeError) Is(target error) bool {
+	switch {
+	case target == ErrClientError:
+		return t.Code >= 400 && t.Code < 500
+	case target == ErrServerError:
+		return t.Code >= 500 && t.Code < 600
+	}
+	sentinel, ok := statusErrors[t.Code]
+	return ok && target == sentinel
+}
+
+// WithErrorDecoder decode the body of error responses with the status into
+// the error returned by factory, which must be a pointer for the JSON body to
+// be unmarshaled into it. The decoded error is returned by the request and
+// still matches the StatusCodeError with errors.As. Bodies which don't
+// decode keep the error of the validator
+func WithErrorDecoder(status int, factory func() error) Option {
+	return func(c *Client) {
+		if c.errorDecoders == nil {
+			c.errorDecoders = make(map[int]func() error)
+		}
+		c.errorDecoders[status] = factory
+	}
+}
+
+// decodedError is an error body decoded by WithErrorDecoder, it unwraps to
+// the decoded error and also matches the StatusCodeError of the response
+type decodedError struct {
+	err       error
+	statusErr StatusCodeError
+}
+
+func (t decodedError) Error() string {
+	return t.err.Error()
+}
+
+func (t decodedError) Unwrap() error {
+	return t.err
+}
+
+func (t decodedError) As(target interface{}) bool {
+	return errors.As(t.statusErr, target)
+}
+
+func (t decodedError) Is(target error) bool {
+	return errors.Is(t.statusErr, target)
+}
+
+func (t decodedError) HTTPStatusCode() int {
+	return t.statusErr.Code
+}
+
+// decodeError apply the error decoder registered for the status of err
+func (c *Client) decodeError(err error) error {
+	var statusErr StatusCodeError
+	if len(c.errorDecoders) == 0 || !errors.As(err, &statusErr) {
+		return err
+	}
+	factory, ok := c.errorDecoders[statusErr.Code]
+	// a body cut at the error body limit can't be decoded
+	if !ok || statusErr.Truncated {
+		return err
+	}
+
+	target := factory()
+	if target == nil || json.Unmarshal(statusErr.RawBody, target) != nil {
+		return err
+	}
+	return decodedError{err: target, statusErr: statusErr}
+}
+
+// WithErrorMapper translate failed responses into errors of the caller, e.g.
+// SDK sentinel errors. mapper is called after the validator rejected the
+// response, with the body of the StatusCodeError when there is one, and the
+// error of the validator is kept when it returns nil
+func WithErrorMapper(mapper func(*http.Response) error) Option {
+	return func(c *Client) {
+		c.errorMapper = mapper
+	}
+}
+
+// mapError apply the error mapper and the error decoders to the error of the
+// validator
+func (c *Client) mapError(resp *http.Response, err error) error {
+	if c.errorMapper != nil {
+		var statusErr StatusCodeError
+		if errors.As(err, &statusErr) {
+			resp.Body = ioutil.NopCloser(bytes.NewReader(statusErr.RawBody))
+		}
+		if mapped := c.errorMapper(resp); mapped != nil {
+			return mapped
+		}
+	}
+	return c.decodeError(err)
+}
+
+// ErrorClass is the kind of failure of a request, see ClassifyError
+type ErrorClass int
+
+const (
+	// ErrorClassUnknown is an error which isn't one of the classes below, or nil
+	ErrorClassUnknown ErrorClass = iota
+	// ErrorClassCanceled the context of the request was canceled
+	ErrorClassCanceled
+	// ErrorClassTimeout the request or one of its network operations timed out
+	ErrorClassTimeout
+	// ErrorClassDNS the host name couldn't be resolved
+	ErrorClassDNS
+	// ErrorClassNetwork the connection failed, was refused or was reset
+	ErrorClassNetwork
+	// ErrorClassClient the server answered with a 4xx status
+	ErrorClassClient
+	// ErrorClassServer the server answered with a 5xx status
+	ErrorClassServer
+)
+
+func (c ErrorClass) String() string {
+	switch c {
+	case ErrorClassCanceled:
+		return "canceled"
+	case ErrorClassTimeout:
+		return "timeout"
+	case ErrorClassDNS:
+		return "dns"
+	case ErrorClassNetwork:
+		return "network"
+	case ErrorClassClient:
+		return "client"
+	case ErrorClassServer:
+		return "server"
+	}
+	return "unknown"
+}
+
+type httpStatusCoder interface {
+	HTTPStatusCode() int
+}
+
+// ClassifyError returns the class of an error returned by the client
+func ClassifyError(err error) ErrorClass {
+	if err == nil {
+		return ErrorClassUnknown
+	}
+
+	var coder httpStatusCoder
+	if errors.As(err, &coder) {
+		switch code := coder.HTTPStatusCode(); {
+		case code >= 500:
+			return ErrorClassServer
+		case code >= 400:
+			return ErrorClassClient
+		}
+		return ErrorClassUnknown
+	}
+
+	if errors.Is(err, context.Canceled) {
+		return ErrorClassCanceled
+	}
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return ErrorClassTimeout
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return ErrorClassDNS
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) {
+		return ErrorClassNetwork
+	}
+	// a connection closed by the server before the response, not a body
+	// which failed to parse
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) && (errors.Is(urlErr.Err, io.EOF) || errors.Is(urlErr.Err, io.ErrUnexpectedEOF)) {
+		return ErrorClassNetwork
+	}
+	return ErrorClassUnknown
+}
+
+// IsRetryable reports whether sending the request again may succeed:
+// timeouts, temporary DNS failures, connection failures, 5xx statuses other
+// than 501 and 505, and 408, 425 and 429. Canceled requests, unknown errors
+// and other statuses are permanent
+func IsRetryable(err error) bool {
+	switch ClassifyError(err) {
+	case ErrorClassTimeout, ErrorClassNetwork:
+		return true
+	case ErrorClassDNS:
+		var dnsErr *net.DNSError
+		errors.As(err, &dnsErr)
+		return dnsErr.IsTemporary || dnsErr.IsTimeout
+	case ErrorClassServer, ErrorClassClient:
+		var coder httpStatusCoder
+		errors.As(err, &coder)
+		return retryableStatus(coder.HTTPStatusCode())
+	}
+	return false
+}
+
+func retryableStatus(code int) bool {
+	switch code {
+	case http.StatusRequestTimeout, http.StatusTooEarly, http.StatusTooManyRequests:
+		return true
+	case http.StatusNotImplemented, http.StatusHTTPVersionNotSupported:
+		return false
+	}
+	return code >= 500
+}
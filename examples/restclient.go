@@ -1,7 +1,6 @@
 package main
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -14,15 +13,16 @@ import (
 )
 
 const (
-	GroupApiV1Path = "/api/v1/groups"
-	UserApiV1Path  = "/api/v1/users"
+	ApiV1BasePath  = "/api/v1"
+	GroupApiV1Path = "/groups"
+	UserApiV1Path  = "/users"
 )
 
 func main() {
 	// Start a local HTTP server
 	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
 		switch {
-		case strings.HasPrefix(req.URL.String(), GroupApiV1Path):
+		case strings.HasPrefix(req.URL.String(), ApiV1BasePath+GroupApiV1Path):
 			switch req.Method {
 			case http.MethodDelete:
 				fmt.Printf("deleting group: %v\n", req.URL.String())
@@ -48,7 +48,7 @@ func main() {
 				rw.Write(d)
 				return
 			}
-		case req.URL.String() == UserApiV1Path:
+		case req.URL.String() == ApiV1BasePath+UserApiV1Path:
 			fmt.Printf("create user: %v\n", req.URL.String())
 			data := &User{
 				Id:   "1",
@@ -109,6 +109,7 @@ func NewMyApiClient(endpoint string, options ...cl.Option) MyApiClient {
 }
 
 func NewV1Client(c *cl.Client) V1Client {
+	c = c.WithBasePath(ApiV1BasePath)
 	return &v1Client{
 		group: NewGroupV1Client(c),
 		user:  NewUserV1Client(c),
@@ -175,7 +176,7 @@ func (api *groupV1Client) DeleteGroupContext(ctx context.Context, group string)
 		"id": {group},
 	}
 
-	if err := api.DoRequestNoBody(ctx, http.MethodDelete, GroupApiV1Path, cl.WithQueryOpt(values)); err != nil {
+	if err := api.Delete(ctx, GroupApiV1Path, cl.WithQueryOpt(values)); err != nil {
 		return err
 	}
 	return nil
@@ -186,13 +187,7 @@ func (api *groupV1Client) ListGroups() ([]*Group, error) {
 }
 
 func (api *groupV1Client) ListGroupsContext(ctx context.Context) ([]*Group, error) {
-	var groups []*Group
-	err := api.GetJson(ctx, GroupApiV1Path, &groups)
-	if err != nil {
-		return nil, err
-	}
-
-	return groups, nil
+	return cl.Get[[]*Group](ctx, api.Client, GroupApiV1Path)
 }
 
 type User struct {
@@ -219,19 +214,8 @@ func (api *userV1Client) CreateUser(req *User) (*User, error) {
 }
 
 func (api *userV1Client) CreateUserContext(ctx context.Context, req *User) (*User, error) {
-	data, err := json.Marshal(req)
-	if err != nil {
-		return nil, err
-	}
-
-	headers := make(http.Header, 0)
-	headers.Add("Content-Type", "application/json")
-	headers.Add("Accept", "application/json")
-
 	user := &User{}
-	if err := api.DoRequestJson(ctx, http.MethodPost, UserApiV1Path, user,
-		cl.WithBodyOpt(bytes.NewBuffer(data)),
-		cl.WithHeadersOpt(headers)); err != nil {
+	if err := api.PostJson(ctx, UserApiV1Path, req, user); err != nil {
 		return nil, err
 	}
 	return user, nil
@@ -0,0 +1,140 @@
+package go_http_client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// GatherPolicy sets how a Gather reacts to a failed call
+type GatherPolicy int
+
+const (
+	// GatherFailFast cancel the remaining calls on the first failure
+	GatherFailFast GatherPolicy = iota
+	// GatherCollectErrors let every call finish and report all failures
+	GatherCollectErrors
+)
+
+// GatherCallError is the failure of one of the calls of a Gather
+type GatherCallError struct {
+	Name string
+	Err  error
+}
+
+// GatherError collects the failed calls of a Gather
+type GatherError struct {
+	Errors []GatherCallError
+}
+
+func (t GatherError) Error() string {
+	msgs := make([]string, 0, len(t.Errors))
+	for _, e := range t.Errors {
+		msgs = append(msgs, fmt.Sprintf("%v: %v", e.Name, e.Err))
+	}
+	return fmt.Sprintf("gather error: %v of the calls failed: %v", len(t.Errors), strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the first call error
+func (t GatherError) Unwrap() error {
+	if len(t.Errors) == 0 {
+		return nil
+	}
+	return t.Errors[0].Err
+}
+
+// Gather runs fetch calls concurrently under a shared deadline, the calls are
+// added with GatherCall and their results read once Wait returned:
+//
+//	g := cl.NewGather(ctx, 2*time.Second, cl.GatherCollectErrors)
+//	users := cl.GatherCall(g, "users", 0, func(ctx context.Context) ([]*User, error) {
+//		return cl.Get[[]*User](ctx, c, "/users")
+//	})
+//	err := g.Wait()
+//	list, userErr := users.Value()
+type Gather struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	policy GatherPolicy
+
+	wg   sync.WaitGroup
+	mu   sync.Mutex
+	errs []GatherCallError
+}
+
+// NewGather start a gather bound to ctx, timeout is the shared deadline of
+// all the calls, 0 for none
+func NewGather(ctx context.Context, timeout time.Duration, policy GatherPolicy) *Gather {
+	var cancel context.CancelFunc
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	return &Gather{ctx: ctx, cancel: cancel, policy: policy}
+}
+
+// GatherResult is the typed result of a call, available after Wait
+type GatherResult[T any] struct {
+	value T
+	err   error
+}
+
+// Value returns the result of the call
+func (r *GatherResult[T]) Value() (T, error) {
+	return r.value, r.err
+}
+
+// GatherCall run fn in g, timeout bounds this call only, 0 for the shared
+// deadline. Calls must be added before Wait
+func GatherCall[T any](g *Gather, name string, timeout time.Duration, fn func(ctx context.Context) (T, error)) *GatherResult[T] {
+	r := &GatherResult[T]{}
+
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+
+		ctx, cancel := g.ctx, context.CancelFunc(func() {})
+		if timeout > 0 {
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+		}
+		defer cancel()
+
+		if err := ctx.Err(); err != nil {
+			r.err = err
+		} else {
+			r.value, r.err = fn(ctx)
+		}
+		if r.err != nil {
+			g.fail(name, r.err)
+		}
+	}()
+	return r
+}
+
+func (g *Gather) fail(name string, err error) {
+	g.mu.Lock()
+	g.errs = append(g.errs, GatherCallError{Name: name, Err: err})
+	g.mu.Unlock()
+
+	if g.policy == GatherFailFast {
+		g.cancel()
+	}
+}
+
+// Wait for every call to finish. It returns a GatherError listing the failed
+// calls, with GatherFailFast the calls cancelled by the first failure are
+// listed after it
+func (g *Gather) Wait() error {
+	g.wg.Wait()
+	g.cancel()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if len(g.errs) > 0 {
+		return GatherError{Errors: append([]GatherCallError(nil), g.errs...)}
+	}
+	return nil
+}
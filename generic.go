@@ -0,0 +1,59 @@
+package go_http_client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// Get perform a GET request and return the JSON response decoded into a T
+func Get[T any](ctx context.Context, c *Client, path string, options ...RequestOption) (T, error) {
+	return Do[T](ctx, c, http.MethodGet, path, options...)
+}
+
+// Do perform a request and return the JSON response decoded into a T
+func Do[T any](ctx context.Context, c *Client, method, path string, options ...RequestOption) (T, error) {
+	var out T
+	if err := c.DoRequestJson(ctx, method, path, &out, options...); err != nil {
+		var zero T
+		return zero, err
+	}
+	return out, nil
+}
+
+// JsonArrayParser decode a top level JSON array one element at a time and
+// call fn with each, so large lists are never held in memory. An error from
+// fn stops the decoding and is returned as is. See WithDefaultAcceptOpt to
+// accept application/json
+func JsonArrayParser[T any](fn func(T) error) ResponseParser {
+	return func(resp *http.Response) (e error) {
+		if resp == nil || fn == nil {
+			return fmt.Errorf("JsonArrayParser function error: %v", resp)
+		}
+
+		dec := json.NewDecoder(resp.Body)
+		tok, err := dec.Token()
+		if err != nil {
+			return fmt.Errorf("failed to decode json array: %w", err)
+		}
+		if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+			return fmt.Errorf("failed to decode json array: unexpected %v", tok)
+		}
+
+		for i := 0; dec.More(); i++ {
+			var item T
+			if err := dec.Decode(&item); err != nil {
+				return fmt.Errorf("failed to decode json array item %v: %w", i, err)
+			}
+			if err := fn(item); err != nil {
+				return err
+			}
+		}
+
+		if _, err := dec.Token(); err != nil {
+			return fmt.Errorf("failed to decode json array: %w", err)
+		}
+		return
+	}
+}
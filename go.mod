@@ -1,5 +1,3 @@
 module github.com/Traumeel/go-http-client
 
-go 1.13
-
-require github.com/sirupsen/logrus v1.6.0
+go 1.18
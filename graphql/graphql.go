@@ -0,0 +1,259 @@
+// Package graphql adds GraphQL queries and mutations on top of go-http-client,
+// with error decoding, automatic persisted queries and file uploads following
+// the GraphQL multipart request spec.
+package graphql
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	cl "github.com/Traumeel/go-http-client"
+)
+
+// Location is a position in the query document
+type Location struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// Error is an entry of the errors of a GraphQL response
+type Error struct {
+	Message    string                 `json:"message"`
+	Locations  []Location             `json:"locations,omitempty"`
+	Path       []interface{}          `json:"path,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+func (t Error) Error() string {
+	if len(t.Path) == 0 {
+		return t.Message
+	}
+	path := make([]string, 0, len(t.Path))
+	for _, p := range t.Path {
+		path = append(path, fmt.Sprint(p))
+	}
+	return fmt.Sprintf("%v: %v", strings.Join(path, "."), t.Message)
+}
+
+// Code returns extensions.code, the error code most servers set
+func (t Error) Code() string {
+	code, _ := t.Extensions["code"].(string)
+	return code
+}
+
+// Errors are the errors of a GraphQL response. The data of a response with
+// errors is still decoded, it may be partial
+type Errors []Error
+
+func (t Errors) Error() string {
+	msgs := make([]string, 0, len(t))
+	for _, e := range t {
+		msgs = append(msgs, e.Error())
+	}
+	return "graphql error: " + strings.Join(msgs, "; ")
+}
+
+// Upload is a file variable, sent as a part of a multipart request. Open is
+// called once per attempt so uploads can be retried, Size is 0 when unknown
+type Upload struct {
+	Filename string
+	Open     func() (io.ReadCloser, error)
+	Size     int64
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithPersistedQueries send the hash of the query instead of the query
+// (automatic persisted queries), falling back to the full query when the
+// server doesn't know the hash yet. Requests with uploads always send the
+// query
+func WithPersistedQueries() Option {
+	return func(c *Client) {
+		c.persisted = true
+	}
+}
+
+// Client sends GraphQL operations to the endpoint path of the underlying
+// client
+type Client struct {
+	*cl.Client
+	path      string
+	persisted bool
+}
+
+func NewClient(c *cl.Client, path string, options ...Option) *Client {
+	gc := &Client{Client: c, path: path}
+	for _, opt := range options {
+		opt(gc)
+	}
+	return gc
+}
+
+type request struct {
+	Query         string                 `json:"query,omitempty"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+	Extensions    map[string]interface{} `json:"extensions,omitempty"`
+}
+
+type response struct {
+	Data   json.RawMessage `json:"data"`
+	Errors Errors          `json:"errors"`
+}
+
+// Query run query with variables and decode the data into out, which may be
+// nil. It returns Errors when the response has errors
+func (c *Client) Query(ctx context.Context, query string, variables map[string]interface{}, out interface{}, options ...cl.RequestOption) error {
+	return c.Do(ctx, "", query, variables, out, options...)
+}
+
+// Mutate run a mutation, see Query
+func (c *Client) Mutate(ctx context.Context, mutation string, variables map[string]interface{}, out interface{}, options ...cl.RequestOption) error {
+	return c.Do(ctx, "", mutation, variables, out, options...)
+}
+
+// Do run the named operation of document, operationName may be empty when the
+// document has a single operation
+func (c *Client) Do(ctx context.Context, operationName, document string, variables map[string]interface{}, out interface{}, options ...cl.RequestOption) error {
+	req := request{Query: document, OperationName: operationName, Variables: variables}
+
+	uploads := map[string]*Upload{}
+	if len(variables) > 0 {
+		req.Variables = extractUploads(variables, "variables", uploads).(map[string]interface{})
+	}
+
+	var resp response
+	if len(uploads) > 0 {
+		if err := c.send(ctx, c.multipartOpt(req, uploads), &resp, options); err != nil {
+			return err
+		}
+		return resp.decode(out)
+	}
+
+	if c.persisted {
+		hash := sha256.Sum256([]byte(document))
+		req.Extensions = map[string]interface{}{
+			"persistedQuery": map[string]interface{}{"version": 1, "sha256Hash": hex.EncodeToString(hash[:])},
+		}
+		persisted := req
+		persisted.Query = ""
+		if err := c.send(ctx, cl.WithJsonBodyOpt(persisted), &resp, options); err != nil {
+			return err
+		}
+		if !resp.persistedQueryNotFound() {
+			return resp.decode(out)
+		}
+		resp = response{}
+	}
+
+	if err := c.send(ctx, cl.WithJsonBodyOpt(req), &resp, options); err != nil {
+		return err
+	}
+	return resp.decode(out)
+}
+
+func (c *Client) send(ctx context.Context, body cl.RequestOption, resp *response, options []cl.RequestOption) error {
+	opts := append([]cl.RequestOption{body}, options...)
+	return c.DoRequestJson(ctx, http.MethodPost, c.path, resp, opts...)
+}
+
+func (r *response) decode(out interface{}) error {
+	if out != nil && len(r.Data) > 0 && string(r.Data) != "null" {
+		if err := json.Unmarshal(r.Data, out); err != nil {
+			return fmt.Errorf("failed to decode graphql data: %w", err)
+		}
+	}
+	if len(r.Errors) > 0 {
+		return r.Errors
+	}
+	return nil
+}
+
+func (r *response) persistedQueryNotFound() bool {
+	for _, e := range r.Errors {
+		if e.Code() == "PERSISTED_QUERY_NOT_FOUND" || e.Message == "PersistedQueryNotFound" {
+			return true
+		}
+	}
+	return false
+}
+
+// extractUploads replace the uploads in v with null, recording them by their
+// object path such as variables.files.0
+func extractUploads(v interface{}, path string, uploads map[string]*Upload) interface{} {
+	switch t := v.(type) {
+	case *Upload:
+		uploads[path] = t
+		return nil
+	case Upload:
+		uploads[path] = &t
+		return nil
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, item := range t {
+			m[k] = extractUploads(item, path+"."+k, uploads)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(t))
+		for i, item := range t {
+			s[i] = extractUploads(item, path+"."+strconv.Itoa(i), uploads)
+		}
+		return s
+	case []*Upload:
+		s := make([]interface{}, len(t))
+		for i, item := range t {
+			s[i] = extractUploads(item, path+"."+strconv.Itoa(i), uploads)
+		}
+		return s
+	}
+	return v
+}
+
+// multipartOpt encode the request as a GraphQL multipart request: the
+// operations, the map of files to variable paths, then the files
+func (c *Client) multipartOpt(req request, uploads map[string]*Upload) cl.RequestOption {
+	paths := make([]string, 0, len(uploads))
+	for path := range uploads {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	fileMap := make(map[string][]string, len(paths))
+	for i, path := range paths {
+		fileMap[strconv.Itoa(i)] = []string{path}
+	}
+
+	return func(r *http.Request) (e error) {
+		operations, err := json.Marshal(req)
+		if err != nil {
+			return fmt.Errorf("failed to marshal graphql operations: %w", err)
+		}
+		mapping, err := json.Marshal(fileMap)
+		if err != nil {
+			return fmt.Errorf("failed to marshal graphql file map: %w", err)
+		}
+
+		b := cl.NewMultipartBuilder().
+			Field("operations", string(operations)).
+			Field("map", string(mapping))
+		for i, path := range paths {
+			u := uploads[path]
+			size := u.Size
+			if size <= 0 {
+				size = -1
+			}
+			b.Stream(strconv.Itoa(i), u.Filename, u.Open, size)
+		}
+		return cl.WithMultipartStreamOpt(b)(r)
+	}
+}
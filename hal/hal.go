@@ -0,0 +1,210 @@
+// Package hal decodes HAL documents (application/hal+json) returned through
+// go-http-client: the resource state, _links and _embedded resources, with
+// templated link expansion to follow links with further requests.
+package hal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	cl "github.com/Traumeel/go-http-client"
+)
+
+// ContentType is the HAL media type
+const ContentType = "application/hal+json"
+
+// Link is a HAL link object
+type Link struct {
+	Href        string `json:"href"`
+	Templated   bool   `json:"templated,omitempty"`
+	Type        string `json:"type,omitempty"`
+	Deprecation string `json:"deprecation,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Profile     string `json:"profile,omitempty"`
+	Title       string `json:"title,omitempty"`
+	HrefLang    string `json:"hreflang,omitempty"`
+}
+
+// Expand the URI template of a templated link with vars. Simple {name}
+// expressions and form style {?a,b} and {&a,b} queries are supported,
+// missing variables expand to nothing
+func (l Link) Expand(vars map[string]string) string {
+	if !l.Templated {
+		return l.Href
+	}
+
+	out := strings.Builder{}
+	rest := l.Href
+	for {
+		start := strings.IndexByte(rest, '{')
+		end := strings.IndexByte(rest, '}')
+		if start < 0 || end < start {
+			out.WriteString(rest)
+			return out.String()
+		}
+		out.WriteString(rest[:start])
+		out.WriteString(expandExpression(rest[start+1:end], vars))
+		rest = rest[end+1:]
+	}
+}
+
+func expandExpression(expr string, vars map[string]string) string {
+	op := ""
+	if expr != "" && (expr[0] == '?' || expr[0] == '&') {
+		op, expr = expr[:1], expr[1:]
+	}
+
+	parts := make([]string, 0)
+	for _, name := range strings.Split(expr, ",") {
+		v, ok := vars[name]
+		if !ok {
+			continue
+		}
+		if op == "" {
+			parts = append(parts, url.PathEscape(v))
+			continue
+		}
+		parts = append(parts, url.QueryEscape(name)+"="+url.QueryEscape(v))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	if op == "" {
+		return strings.Join(parts, ",")
+	}
+	return op + strings.Join(parts, "&")
+}
+
+// Resource is a HAL resource. Links and Embedded hold arrays whether the
+// document used a single object or an array for the relation
+type Resource struct {
+	Links    map[string][]Link
+	Embedded map[string][]*Resource
+	// State is the whole resource object, _links and _embedded included
+	State json.RawMessage
+
+	base *url.URL
+}
+
+func (r *Resource) UnmarshalJSON(data []byte) error {
+	var doc struct {
+		Links    map[string]json.RawMessage `json:"_links"`
+		Embedded map[string]json.RawMessage `json:"_embedded"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+
+	r.State = append(json.RawMessage(nil), data...)
+	r.Links = make(map[string][]Link, len(doc.Links))
+	for rel, raw := range doc.Links {
+		var links []Link
+		if err := unmarshalOneOrMany(raw, &links); err != nil {
+			return fmt.Errorf("failed to decode link %v: %w", rel, err)
+		}
+		r.Links[rel] = links
+	}
+
+	r.Embedded = make(map[string][]*Resource, len(doc.Embedded))
+	for rel, raw := range doc.Embedded {
+		var resources []*Resource
+		if err := unmarshalOneOrMany(raw, &resources); err != nil {
+			return fmt.Errorf("failed to decode embedded %v: %w", rel, err)
+		}
+		r.Embedded[rel] = resources
+	}
+	return nil
+}
+
+func unmarshalOneOrMany[T any](raw json.RawMessage, dst *[]T) error {
+	trimmed := strings.TrimSpace(string(raw))
+	if strings.HasPrefix(trimmed, "[") {
+		return json.Unmarshal(raw, dst)
+	}
+	var one T
+	if err := json.Unmarshal(raw, &one); err != nil {
+		return err
+	}
+	*dst = []T{one}
+	return nil
+}
+
+// Decode unmarshal the resource state into v
+func (r *Resource) Decode(v interface{}) error {
+	return json.Unmarshal(r.State, v)
+}
+
+// Link returns the first link of rel
+func (r *Resource) Link(rel string) (Link, bool) {
+	links := r.Links[rel]
+	if len(links) == 0 {
+		return Link{}, false
+	}
+	return links[0], true
+}
+
+// URL resolve the href of a link, expanded with vars when templated, against
+// the URL the resource was fetched from
+func (r *Resource) URL(l Link, vars map[string]string) (string, error) {
+	u, err := url.Parse(l.Expand(vars))
+	if err != nil {
+		return "", err
+	}
+	if r.base != nil {
+		u = r.base.ResolveReference(u)
+	}
+	return u.String(), nil
+}
+
+func (r *Resource) setBase(base *url.URL) {
+	r.base = base
+	for _, resources := range r.Embedded {
+		for _, e := range resources {
+			e.setBase(base)
+		}
+	}
+}
+
+// Parser decode a HAL document into r
+func Parser(r *Resource) cl.ResponseParser {
+	return func(resp *http.Response) (e error) {
+		if resp == nil || r == nil {
+			return fmt.Errorf("hal.Parser function error: %v | %v", resp, r)
+		}
+		if err := json.NewDecoder(resp.Body).Decode(r); err != nil {
+			return err
+		}
+		if resp.Request != nil {
+			r.setBase(resp.Request.URL)
+		}
+		return
+	}
+}
+
+// Get fetch the resource at path, setting the HAL Accept header
+func Get(ctx context.Context, c *cl.Client, path string, options ...cl.RequestOption) (*Resource, error) {
+	r := &Resource{}
+	opts := append([]cl.RequestOption{cl.WithHeadersOpt(http.Header{"Accept": {ContentType}})}, options...)
+	return r, c.DoRequest(ctx, http.MethodGet, path, Parser(r), opts...)
+}
+
+// Follow fetch the resource behind the first rel link of r, expanding it with
+// vars when templated. It returns nil without error when r has no such link
+func Follow(ctx context.Context, c *cl.Client, r *Resource, rel string, vars map[string]string, options ...cl.RequestOption) (*Resource, error) {
+	link, ok := r.Link(rel)
+	if !ok {
+		return nil, nil
+	}
+	u, err := r.URL(link, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	next := &Resource{}
+	opts := append([]cl.RequestOption{cl.WithHeadersOpt(http.Header{"Accept": {ContentType}})}, options...)
+	return next, c.DoRequestURL(ctx, http.MethodGet, u, Parser(next), opts...)
+}
@@ -0,0 +1,345 @@
+package go_http_client
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// HARRecorder collects the exchanges of a client in HTTP Archive 1.2 format,
+// to inspect them in browser devtools or share them. Secrets are redacted as
+// in the debug dumps. Entries are kept in memory until Reset, up to
+// DefaultHAREntries, see SetMaxEntries
+type HARRecorder struct {
+	mu      sync.Mutex
+	entries []harEntry
+	// next is the entry replaced by the next record once entries is full
+	next       int
+	maxEntries int
+	maxBody    int64
+	// path is where Flush saves the entries, if set
+	path string
+}
+
+// DefaultHARBodyBytes is the amount of a body recorded unless set with
+// NewHARRecorder
+const DefaultHARBodyBytes = 1 << 20
+
+// DefaultHAREntries is the number of entries kept unless set with
+// SetMaxEntries, the oldest are dropped first
+const DefaultHAREntries = 1000
+
+// harNotCaptured replaces the bodies of streaming responses
+const harNotCaptured = "[not captured]"
+
+// NewHARRecorder record at most maxBodyBytes of each body, DefaultHARBodyBytes
+// when not positive. Only that part is read before the caller gets the
+// response, and streaming bodies such as text/event-stream responses or
+// WithMultipartStreamOpt uploads aren't read
+func NewHARRecorder(maxBodyBytes int64) *HARRecorder {
+	if maxBodyBytes <= 0 {
+		maxBodyBytes = DefaultHARBodyBytes
+	}
+	return &HARRecorder{maxBody: maxBodyBytes, maxEntries: DefaultHAREntries}
+}
+
+// SetMaxEntries keep only the n latest entries, DefaultHAREntries when not
+// positive
+func (h *HARRecorder) SetMaxEntries(n int) {
+	if n <= 0 {
+		n = DefaultHAREntries
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	entries := h.ordered()
+	if len(entries) > n {
+		entries = entries[len(entries)-n:]
+	}
+	h.entries, h.next, h.maxEntries = entries, 0, n
+}
+
+// ordered returns the entries oldest first, the caller holds the lock
+func (h *HARRecorder) ordered() []harEntry {
+	return append(append([]harEntry{}, h.entries[h.next:]...), h.entries[:h.next]...)
+}
+
+// NewHARFileRecorder returns a recorder as NewHARRecorder which saves its
+// entries to path on Flush, which Client.Shutdown calls
+func NewHARFileRecorder(path string, maxBodyBytes int64) *HARRecorder {
+	h := NewHARRecorder(maxBodyBytes)
+	h.path = path
+	return h
+}
+
+// Flush write the recorded entries to the file of a NewHARFileRecorder,
+// replacing it. It does nothing for other recorders
+func (h *HARRecorder) Flush(ctx context.Context) error {
+	if h.path == "" {
+		return nil
+	}
+	dir := filepath.Dir(h.path)
+	tmp, err := ioutil.TempFile(dir, filepath.Base(h.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := h.WriteTo(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), h.path)
+}
+
+// WithHARRecorder record the exchanges of the client into h
+func WithHARRecorder(h *HARRecorder) Option {
+	return func(c *Client) {
+		c.har = h
+	}
+}
+
+// Len returns the number of recorded entries
+func (h *HARRecorder) Len() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.entries)
+}
+
+// Reset drop the recorded entries
+func (h *HARRecorder) Reset() {
+	h.mu.Lock()
+	h.entries, h.next = nil, 0
+	h.mu.Unlock()
+}
+
+// WriteTo write the recorded entries as a HAR document
+func (h *HARRecorder) WriteTo(w io.Writer) (int64, error) {
+	h.mu.Lock()
+	doc := harDocument{Log: harLog{
+		Version: "1.2",
+		Creator: harCreator{Name: "go-http-client", Version: "1.0"},
+		Entries: h.ordered(),
+	}}
+	h.mu.Unlock()
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(data)
+	return int64(n), err
+}
+
+// record add the exchange, the recorded part of the response body is read
+// and replaced
+func (h *HARRecorder) record(req *http.Request, resp *http.Response, meta *Response, start time.Time, r *redactor) error {
+	content := harContent{Size: resp.ContentLength, MimeType: resp.Header.Get("Content-Type"), Text: harNotCaptured}
+	if !isStreamingMediaType(mediaType(resp.Header)) {
+		respBody, truncated, err := readBody(&resp.Body, h.maxBody)
+		if err != nil {
+			return err
+		}
+		content = harBody(resp.Header, respBody, truncated, r)
+		if truncated {
+			content.Size = resp.ContentLength
+		}
+	}
+
+	u := r.url(req.URL)
+	entry := harEntry{
+		StartedDateTime: start.Format(time.RFC3339Nano),
+		Time:            harMillis(meta.Duration),
+		Request: harRequest{
+			Method:      req.Method,
+			URL:         u.String(),
+			HTTPVersion: resp.Proto,
+			Cookies:     []harNameValue{},
+			Headers:     harHeaders(r.header(req.Header)),
+			QueryString: []harNameValue{},
+			HeadersSize: -1,
+			BodySize:    req.ContentLength,
+		},
+		Response: harResponse{
+			Status:      resp.StatusCode,
+			StatusText:  http.StatusText(resp.StatusCode),
+			HTTPVersion: resp.Proto,
+			Cookies:     []harNameValue{},
+			Headers:     harHeaders(r.header(resp.Header)),
+			Content:     content,
+			RedirectURL: resp.Header.Get("Location"),
+			HeadersSize: -1,
+			BodySize:    content.Size,
+		},
+		Cache: struct{}{},
+		Timings: harTimings{
+			DNS:     harPhase(meta.Timings.DNS),
+			Connect: harPhase(meta.Timings.Connect + meta.Timings.TLS),
+			SSL:     harPhase(meta.Timings.TLS),
+			Wait:    harMillis(meta.Timings.TTFB),
+		},
+	}
+	for k, values := range u.Query() {
+		for _, v := range values {
+			entry.Request.QueryString = append(entry.Request.QueryString, harNameValue{Name: k, Value: v})
+		}
+	}
+	sort.Slice(entry.Request.QueryString, func(a, b int) bool {
+		return entry.Request.QueryString[a].Name < entry.Request.QueryString[b].Name
+	})
+
+	// the body was sent already, only a replayable one can be recorded and
+	// streamed uploads aren't read again
+	if req.GetBody != nil && !isStreamedBody(req) && !isStreamingMediaType(mediaType(req.Header)) {
+		if body, err := req.GetBody(); err == nil {
+			data, truncated, err := readBody(&body, h.maxBody)
+			body.Close()
+			if err == nil && len(data) > 0 {
+				content := harBody(req.Header, data, truncated, r)
+				entry.Request.PostData = &harPostData{MimeType: content.MimeType, Text: content.Text}
+			}
+		}
+	} else if hasBody(req) {
+		entry.Request.PostData = &harPostData{MimeType: req.Header.Get("Content-Type"), Text: harNotCaptured}
+	}
+
+	h.mu.Lock()
+	if len(h.entries) < h.maxEntries {
+		h.entries = append(h.entries, entry)
+	} else {
+		h.entries[h.next] = entry
+		h.next = (h.next + 1) % h.maxEntries
+	}
+	h.mu.Unlock()
+	return nil
+}
+
+// harBody returns the content of body, truncated is set when body is only
+// the start of it
+func harBody(header http.Header, body []byte, truncated bool, r *redactor) harContent {
+	c := harContent{Size: int64(len(body)), MimeType: header.Get("Content-Type")}
+	if truncated {
+		c.Comment = "truncated"
+	}
+	mt := mediaType(header)
+	switch {
+	case len(r.jsonRules) > 0 && isJSONMediaType(mt) && truncated:
+		// a cut document can't be parsed, hence redacted
+		c.Text = harNotCaptured
+	case len(r.jsonRules) > 0 && isJSONMediaType(mt):
+		c.Text = string(r.redactJSONBody(body))
+	case utf8.Valid(body):
+		c.Text = string(body)
+	default:
+		c.Text, c.Encoding = base64.StdEncoding.EncodeToString(body), "base64"
+	}
+	return c
+}
+
+func harHeaders(h http.Header) []harNameValue {
+	out := []harNameValue{}
+	for _, k := range sortedKeys(h) {
+		for _, v := range h[k] {
+			out = append(out, harNameValue{Name: k, Value: v})
+		}
+	}
+	return out
+}
+
+func harMillis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// harPhase returns -1 for the phases which didn't happen, as HAR expects
+func harPhase(d time.Duration) float64 {
+	if d == 0 {
+		return -1
+	}
+	return harMillis(d)
+}
+
+type harDocument struct {
+	Log harLog `json:"log"`
+}
+
+type harLog struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int64          `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int64          `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harContent struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+type harTimings struct {
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	SSL     float64 `json:"ssl"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
@@ -0,0 +1,55 @@
+package go_http_client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestHARMaxEntries(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	h := NewHARRecorder(0)
+	h.SetMaxEntries(2)
+	c := NewClient(srv.URL, WithHARRecorder(h))
+	for i := 0; i < 5; i++ {
+		if err := c.DoRequestNoBody(context.Background(), http.MethodGet, "/"+strconv.Itoa(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if _, err := h.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var doc harDocument
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Log.Entries) != 2 || doc.Log.Entries[0].Request.URL != srv.URL+"/3" || doc.Log.Entries[1].Request.URL != srv.URL+"/4" {
+		t.Fatalf("entries = %+v, want the 2 latest", doc.Log.Entries)
+	}
+}
+
+func TestHARReadErrorKeepsStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the body is cut short of its announced length
+		w.Header().Set("Content-Length", "100")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte("short"))
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, WithHARRecorder(NewHARRecorder(0)), WithLog(newRecordingLogger()))
+	err := c.DoRequestNoBody(context.Background(), http.MethodGet, "/")
+	var statusErr StatusCodeError
+	if !errors.As(err, &statusErr) || statusErr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("err = %v, want the 503 StatusCodeError", err)
+	}
+}
@@ -0,0 +1,75 @@
+package go_http_client
+
+import (
+	"net/http"
+)
+
+// RequestHook is called with each request before it is sent
+type RequestHook func(req *http.Request)
+
+// ResponseHook is called with each received response, also when the
+// validator rejects it afterwards. resp carries the status, the duration and
+// the number of attempts
+type ResponseHook func(req *http.Request, resp *Response)
+
+// ErrorHook is called with the error a request terminated with, whether it
+// failed to be sent, was rejected by the validator or failed to parse. resp
+// is nil when no response was received
+type ErrorHook func(req *http.Request, resp *Response, err error)
+
+// hooks are called synchronously in the order they were registered, they
+// should be quick and must not modify the request
+type hooks struct {
+	onRequest  []RequestHook
+	onResponse []ResponseHook
+	onError    []ErrorHook
+}
+
+// OnRequest register a hook called before each request is sent. Every
+// request reported to it is later reported to the OnResponse or OnError
+// hooks, or both when the response was rejected
+func OnRequest(h RequestHook) Option {
+	return func(c *Client) {
+		c.hooks.onRequest = append(c.hooks.onRequest, h)
+	}
+}
+
+// OnResponse register a hook called when a response was received
+func OnResponse(h ResponseHook) Option {
+	return func(c *Client) {
+		c.hooks.onResponse = append(c.hooks.onResponse, h)
+	}
+}
+
+// OnError register a hook called with the terminal error of a request
+func OnError(h ErrorHook) Option {
+	return func(c *Client) {
+		c.hooks.onError = append(c.hooks.onError, h)
+	}
+}
+
+func (h hooks) clone() hooks {
+	return hooks{
+		onRequest:  append([]RequestHook(nil), h.onRequest...),
+		onResponse: append([]ResponseHook(nil), h.onResponse...),
+		onError:    append([]ErrorHook(nil), h.onError...),
+	}
+}
+
+func (h hooks) request(req *http.Request) {
+	for _, fn := range h.onRequest {
+		fn(req)
+	}
+}
+
+func (h hooks) response(req *http.Request, resp *Response) {
+	for _, fn := range h.onResponse {
+		fn(req, resp)
+	}
+}
+
+func (h hooks) error(req *http.Request, resp *Response, err error) {
+	for _, fn := range h.onError {
+		fn(req, resp, err)
+	}
+}
@@ -0,0 +1,169 @@
+package go_http_client
+
+import (
+	"net/http"
+	"net/http/httptrace"
+	"sort"
+	"sync"
+	"time"
+)
+
+// RequestState is the phase an in-flight request is in
+type RequestState string
+
+const (
+	StateDialing RequestState = "dialing"
+	StateSending RequestState = "sending"
+	StateWaiting RequestState = "waiting"
+	StateReading RequestState = "reading"
+)
+
+// InFlightRequest is a snapshot of a request which hasn't finished yet, a
+// request finishes when its response body is closed
+type InFlightRequest struct {
+	ID         uint64
+	Method     string
+	URL        string
+	Operation  string
+	Start      time.Time
+	Attempt    int
+	State      RequestState
+	StateSince time.Time
+}
+
+// InFlight returns the requests currently in flight on the client and its
+// clones, oldest first
+func (c *Client) InFlight() []InFlightRequest {
+	return c.journal.snapshot()
+}
+
+type journal struct {
+	mu      sync.Mutex
+	nextID  uint64
+	entries map[uint64]*InFlightRequest
+	closed  bool
+	conns   TransportStats
+	// idle is closed when the journal is closed and the last entry is done
+	idle chan struct{}
+}
+
+func newJournal() *journal {
+	return &journal{entries: make(map[uint64]*InFlightRequest)}
+}
+
+type journalEntry struct {
+	j  *journal
+	id uint64
+	// hasConn is set once an attempt got a connection, guarded by the
+	// journal lock
+	hasConn bool
+}
+
+func (j *journal) start(req *http.Request) (*http.Request, *journalEntry, error) {
+	now := time.Now()
+
+	j.mu.Lock()
+	if j.closed {
+		j.mu.Unlock()
+		return nil, nil, ErrClientClosed
+	}
+	j.nextID++
+	id := j.nextID
+	j.entries[id] = &InFlightRequest{
+		ID:         id,
+		Method:     req.Method,
+		URL:        RedactedURL(req),
+		Operation:  OperationFromContext(req.Context()),
+		Start:      now,
+		Attempt:    1,
+		State:      StateSending,
+		StateSince: now,
+	}
+	j.mu.Unlock()
+
+	e := &journalEntry{j: j, id: id}
+	trace := &httptrace.ClientTrace{
+		GetConn: func(string) {
+			e.setState(StateDialing)
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			e.setState(StateSending)
+			j.mu.Lock()
+			j.gotConn(e, info)
+			j.mu.Unlock()
+		},
+		PutIdleConn: j.putIdleConn,
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			e.setState(StateWaiting)
+		},
+		GotFirstResponseByte: func() {
+			e.setState(StateReading)
+		},
+	}
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), trace)), e, nil
+}
+
+func (e *journalEntry) setState(state RequestState) {
+	e.j.mu.Lock()
+	defer e.j.mu.Unlock()
+	if entry, ok := e.j.entries[e.id]; ok {
+		entry.State = state
+		entry.StateSince = time.Now()
+	}
+}
+
+func (e *journalEntry) setAttempt(attempt int) {
+	e.j.mu.Lock()
+	defer e.j.mu.Unlock()
+	if entry, ok := e.j.entries[e.id]; ok {
+		entry.Attempt = attempt
+	}
+}
+
+func (e *journalEntry) done() {
+	e.j.mu.Lock()
+	defer e.j.mu.Unlock()
+	delete(e.j.entries, e.id)
+	if e.hasConn {
+		e.hasConn = false
+		e.j.conns.Active--
+	}
+	e.j.signalIdle()
+}
+
+// close stop accepting requests and returns a channel closed once the
+// requests in flight are done
+func (j *journal) close() <-chan struct{} {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if !j.closed {
+		j.closed = true
+		j.idle = make(chan struct{})
+		j.signalIdle()
+	}
+	return j.idle
+}
+
+func (j *journal) signalIdle() {
+	if j.closed && len(j.entries) == 0 {
+		select {
+		case <-j.idle:
+		default:
+			close(j.idle)
+		}
+	}
+}
+
+func (j *journal) snapshot() []InFlightRequest {
+	j.mu.Lock()
+	out := make([]InFlightRequest, 0, len(j.entries))
+	for _, e := range j.entries {
+		out = append(out, *e)
+	}
+	j.mu.Unlock()
+
+	sort.Slice(out, func(a, b int) bool {
+		return out[a].ID < out[b].ID
+	})
+	return out
+}
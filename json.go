@@ -0,0 +1,84 @@
+package go_http_client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// ErrJsonTrailingData is returned by parsers with DisallowTrailingData when
+// the JSON value is followed by more data
+var ErrJsonTrailingData = errors.New("unexpected data after the JSON value")
+
+type jsonParserConfig struct {
+	disallowUnknownFields bool
+	useNumber             bool
+	disallowTrailingData  bool
+}
+
+// JsonParserOption tunes the decoding of JsonParserWithOptions
+type JsonParserOption func(*jsonParserConfig)
+
+// DisallowUnknownFields fail on object keys without a matching field in the
+// destination, see json.Decoder.DisallowUnknownFields
+func DisallowUnknownFields() JsonParserOption {
+	return func(c *jsonParserConfig) {
+		c.disallowUnknownFields = true
+	}
+}
+
+// UseNumber decode numbers into interface{} values as json.Number instead of
+// float64, keeping the precision of large integers
+func UseNumber() JsonParserOption {
+	return func(c *jsonParserConfig) {
+		c.useNumber = true
+	}
+}
+
+// DisallowTrailingData fail when anything but whitespace follows the JSON
+// value, see ErrJsonTrailingData
+func DisallowTrailingData() JsonParserOption {
+	return func(c *jsonParserConfig) {
+		c.disallowTrailingData = true
+	}
+}
+
+// JsonParserStrict decode like JsonParser but fail on unknown fields and
+// trailing data, to catch contract drift instead of silently dropping fields
+func JsonParserStrict(dst interface{}) ResponseParser {
+	return JsonParserWithOptions(dst, DisallowUnknownFields(), DisallowTrailingData())
+}
+
+// JsonParserWithOptions decode like JsonParser with the given options
+func JsonParserWithOptions(dst interface{}, options ...JsonParserOption) ResponseParser {
+	cfg := jsonParserConfig{}
+	for _, opt := range options {
+		opt(&cfg)
+	}
+
+	return func(resp *http.Response) (e error) {
+		if resp == nil || dst == nil {
+			return fmt.Errorf("JsonParserWithOptions function error: %v | %v", resp, dst)
+		}
+
+		dec := json.NewDecoder(resp.Body)
+		if cfg.disallowUnknownFields {
+			dec.DisallowUnknownFields()
+		}
+		if cfg.useNumber {
+			dec.UseNumber()
+		}
+		if err := dec.Decode(dst); err != nil {
+			return err
+		}
+		if cfg.disallowTrailingData {
+			if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+				return ErrJsonTrailingData
+			}
+		}
+		setResponseMetadata(dst, resp)
+		return
+	}
+}
@@ -0,0 +1,228 @@
+// Package jsonapi decodes JSON:API documents (https://jsonapi.org) returned
+// through go-http-client: primary data, included resources, relationships
+// and links, which can be followed with further requests.
+package jsonapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	cl "github.com/Traumeel/go-http-client"
+)
+
+// ContentType is the JSON:API media type
+const ContentType = "application/vnd.api+json"
+
+// ErrNotFound is returned when a resource isn't part of the document
+var ErrNotFound = errors.New("resource not found in the document")
+
+// Link is a link object, links given as plain strings only have an Href
+type Link struct {
+	Href string                 `json:"href"`
+	Meta map[string]interface{} `json:"meta,omitempty"`
+}
+
+func (l *Link) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		return json.Unmarshal(data, &l.Href)
+	}
+	type link Link
+	return json.Unmarshal(data, (*link)(l))
+}
+
+// Links maps link names such as "self", "next" or "related" to links
+type Links map[string]*Link
+
+// Href returns the href of the named link, "" when missing
+func (l Links) Href(name string) string {
+	if link := l[name]; link != nil {
+		return link.Href
+	}
+	return ""
+}
+
+// Identifier identifies a resource
+type Identifier struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+// Relationship is a relationship of a resource, Data holds one identifier,
+// an array of them or null
+type Relationship struct {
+	Data  json.RawMessage        `json:"data,omitempty"`
+	Links Links                  `json:"links,omitempty"`
+	Meta  map[string]interface{} `json:"meta,omitempty"`
+}
+
+// Identifiers returns the related resources, none for an empty to-one
+// relationship
+func (r Relationship) Identifiers() ([]Identifier, error) {
+	data := bytes.TrimSpace(r.Data)
+	if len(data) == 0 || string(data) == "null" {
+		return nil, nil
+	}
+	if data[0] == '[' {
+		var ids []Identifier
+		return ids, json.Unmarshal(data, &ids)
+	}
+	var id Identifier
+	if err := json.Unmarshal(data, &id); err != nil {
+		return nil, err
+	}
+	return []Identifier{id}, nil
+}
+
+// Resource is a resource object
+type Resource struct {
+	Type          string                  `json:"type"`
+	ID            string                  `json:"id"`
+	Attributes    json.RawMessage         `json:"attributes,omitempty"`
+	Relationships map[string]Relationship `json:"relationships,omitempty"`
+	Links         Links                   `json:"links,omitempty"`
+	Meta          map[string]interface{}  `json:"meta,omitempty"`
+}
+
+// Decode unmarshal the attributes into v
+func (r Resource) Decode(v interface{}) error {
+	if len(r.Attributes) == 0 {
+		return nil
+	}
+	return json.Unmarshal(r.Attributes, v)
+}
+
+// Identifier returns the identifier of the resource
+func (r Resource) Identifier() Identifier {
+	return Identifier{Type: r.Type, ID: r.ID}
+}
+
+// ErrorObject is an entry of the errors of a document
+type ErrorObject struct {
+	ID     string                 `json:"id,omitempty"`
+	Status string                 `json:"status,omitempty"`
+	Code   string                 `json:"code,omitempty"`
+	Title  string                 `json:"title,omitempty"`
+	Detail string                 `json:"detail,omitempty"`
+	Source map[string]interface{} `json:"source,omitempty"`
+	Meta   map[string]interface{} `json:"meta,omitempty"`
+}
+
+// Document is a JSON:API top level document
+type Document struct {
+	Data     json.RawMessage        `json:"data,omitempty"`
+	Included []Resource             `json:"included,omitempty"`
+	Links    Links                  `json:"links,omitempty"`
+	Meta     map[string]interface{} `json:"meta,omitempty"`
+	Errors   []ErrorObject          `json:"errors,omitempty"`
+
+	// base is the URL of the request, relative links resolve against it
+	base *url.URL
+}
+
+// Resources returns the primary data, a single resource as a one element
+// slice and null as none
+func (d *Document) Resources() ([]Resource, error) {
+	data := bytes.TrimSpace(d.Data)
+	if len(data) == 0 || string(data) == "null" {
+		return nil, nil
+	}
+	if data[0] == '[' {
+		var resources []Resource
+		return resources, json.Unmarshal(data, &resources)
+	}
+	var r Resource
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, err
+	}
+	return []Resource{r}, nil
+}
+
+// Find returns the resource with the given identifier from the primary data
+// or the included resources
+func (d *Document) Find(id Identifier) (Resource, error) {
+	resources, err := d.Resources()
+	if err != nil {
+		return Resource{}, err
+	}
+	for _, r := range append(resources, d.Included...) {
+		if r.Type == id.Type && r.ID == id.ID {
+			return r, nil
+		}
+	}
+	return Resource{}, fmt.Errorf("%w: %v %v", ErrNotFound, id.Type, id.ID)
+}
+
+// Related returns the resources of a relationship of r found in the
+// document, typically with ?include=name
+func (d *Document) Related(r Resource, name string) ([]Resource, error) {
+	ids, err := r.Relationships[name].Identifiers()
+	if err != nil {
+		return nil, err
+	}
+	related := make([]Resource, 0, len(ids))
+	for _, id := range ids {
+		res, err := d.Find(id)
+		if err != nil {
+			return nil, err
+		}
+		related = append(related, res)
+	}
+	return related, nil
+}
+
+// URL resolve href against the URL the document was fetched from
+func (d *Document) URL(href string) (string, error) {
+	u, err := url.Parse(href)
+	if err != nil {
+		return "", err
+	}
+	if d.base != nil {
+		u = d.base.ResolveReference(u)
+	}
+	return u.String(), nil
+}
+
+// Parser decode a JSON:API document into doc
+func Parser(doc *Document) cl.ResponseParser {
+	return func(resp *http.Response) (e error) {
+		if resp == nil || doc == nil {
+			return fmt.Errorf("jsonapi.Parser function error: %v | %v", resp, doc)
+		}
+		if err := json.NewDecoder(resp.Body).Decode(doc); err != nil {
+			return err
+		}
+		if resp.Request != nil {
+			doc.base = resp.Request.URL
+		}
+		return
+	}
+}
+
+// Get fetch the document at path, setting the JSON:API Accept header
+func Get(ctx context.Context, c *cl.Client, path string, options ...cl.RequestOption) (*Document, error) {
+	doc := &Document{}
+	opts := append([]cl.RequestOption{cl.WithHeadersOpt(http.Header{"Accept": {ContentType}})}, options...)
+	return doc, c.DoRequest(ctx, http.MethodGet, path, Parser(doc), opts...)
+}
+
+// Follow fetch the document behind the named link of doc, such as "next"
+// for pagination. It returns nil without error when doc has no such link
+func Follow(ctx context.Context, c *cl.Client, doc *Document, name string, options ...cl.RequestOption) (*Document, error) {
+	href := doc.Links.Href(name)
+	if href == "" {
+		return nil, nil
+	}
+	u, err := doc.URL(href)
+	if err != nil {
+		return nil, err
+	}
+
+	next := &Document{}
+	opts := append([]cl.RequestOption{cl.WithHeadersOpt(http.Header{"Accept": {ContentType}})}, options...)
+	return next, c.DoRequestURL(ctx, http.MethodGet, u, Parser(next), opts...)
+}
@@ -0,0 +1,192 @@
+// Package jsonrpc adds JSON-RPC 2.0 calls, notifications and batches on top
+// of go-http-client, reusing its transport and request options.
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+
+	cl "github.com/Traumeel/go-http-client"
+)
+
+// Version is the protocol version sent in every request
+const Version = "2.0"
+
+// Error codes defined by the specification, -32000 to -32099 are reserved
+// for implementation defined server errors
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+// ErrMissingResponse is returned for a batched call the server didn't answer
+var ErrMissingResponse = errors.New("no response for the call")
+
+// Error is the error object of a JSON-RPC response
+type Error struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (t *Error) Error() string {
+	return fmt.Sprintf("jsonrpc error %v: %v", t.Code, t.Message)
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithIDGenerator generate the request IDs with fn instead of a counter, the
+// IDs must be strings or numbers and unique within a batch
+func WithIDGenerator(fn func() interface{}) Option {
+	return func(c *Client) {
+		c.nextID = fn
+	}
+}
+
+// Client sends JSON-RPC requests to the endpoint path of the underlying client
+type Client struct {
+	*cl.Client
+	path   string
+	nextID func() interface{}
+}
+
+func NewClient(c *cl.Client, path string, options ...Option) *Client {
+	counter := int64(0)
+	rc := &Client{
+		Client: c,
+		path:   path,
+		nextID: func() interface{} {
+			return atomic.AddInt64(&counter, 1)
+		},
+	}
+	for _, opt := range options {
+		opt(rc)
+	}
+	return rc
+}
+
+type request struct {
+	Version string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+	ID      interface{} `json:"id,omitempty"`
+}
+
+type response struct {
+	Version string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result"`
+	Error   *Error          `json:"error"`
+	ID      json.RawMessage `json:"id"`
+}
+
+func (r *response) decode(result interface{}) error {
+	if r.Error != nil {
+		return r.Error
+	}
+	if result == nil || len(r.Result) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(r.Result, result); err != nil {
+		return fmt.Errorf("failed to decode jsonrpc result: %w", err)
+	}
+	return nil
+}
+
+// Call invoke method with params, an array or an object, and decode the
+// result into result, which may be nil. A JSON-RPC error is returned as *Error
+func (c *Client) Call(ctx context.Context, method string, params, result interface{}, options ...cl.RequestOption) error {
+	req := request{Version: Version, Method: method, Params: params, ID: c.nextID()}
+
+	var resp response
+	opts := append([]cl.RequestOption{cl.WithJsonBodyOpt(req)}, options...)
+	if err := c.DoRequestJson(ctx, http.MethodPost, c.path, &resp, opts...); err != nil {
+		return err
+	}
+	return resp.decode(result)
+}
+
+// Notify send a notification, which gets no response
+func (c *Client) Notify(ctx context.Context, method string, params interface{}, options ...cl.RequestOption) error {
+	req := request{Version: Version, Method: method, Params: params}
+	opts := append([]cl.RequestOption{cl.WithJsonBodyOpt(req)}, options...)
+	return c.DoRequest(ctx, http.MethodPost, c.path, cl.NoBodyParser(nil), opts...)
+}
+
+// BatchCall is a call of a batch, its outcome is known once the batch was sent
+type BatchCall struct {
+	id     string
+	result interface{}
+	err    error
+}
+
+// Err returns the error of the call, an *Error or ErrMissingResponse
+func (b *BatchCall) Err() error {
+	return b.err
+}
+
+// Batch collects calls and notifications sent in a single request
+type Batch struct {
+	c        *Client
+	requests []request
+	calls    []*BatchCall
+}
+
+// NewBatch start an empty batch
+func (c *Client) NewBatch() *Batch {
+	return &Batch{c: c}
+}
+
+// Call add a call whose result is decoded into result when the batch is sent
+func (b *Batch) Call(method string, params, result interface{}) *BatchCall {
+	req := request{Version: Version, Method: method, Params: params, ID: b.c.nextID()}
+	id, _ := json.Marshal(req.ID)
+	call := &BatchCall{id: string(id), result: result}
+	b.requests = append(b.requests, req)
+	b.calls = append(b.calls, call)
+	return call
+}
+
+// Notify add a notification
+func (b *Batch) Notify(method string, params interface{}) {
+	b.requests = append(b.requests, request{Version: Version, Method: method, Params: params})
+}
+
+// Send the batch. The returned error is about the request as a whole, the
+// outcome of each call is reported by its Err
+func (b *Batch) Send(ctx context.Context, options ...cl.RequestOption) error {
+	if len(b.requests) == 0 {
+		return nil
+	}
+
+	opts := append([]cl.RequestOption{cl.WithJsonBodyOpt(b.requests)}, options...)
+	if len(b.calls) == 0 {
+		return b.c.DoRequest(ctx, http.MethodPost, b.c.path, cl.NoBodyParser(nil), opts...)
+	}
+
+	var responses []response
+	if err := b.c.DoRequestJson(ctx, http.MethodPost, b.c.path, &responses, opts...); err != nil {
+		return err
+	}
+
+	byID := make(map[string]*response, len(responses))
+	for i := range responses {
+		byID[string(responses[i].ID)] = &responses[i]
+	}
+	for _, call := range b.calls {
+		resp, ok := byID[call.id]
+		if !ok {
+			call.err = ErrMissingResponse
+			continue
+		}
+		call.err = resp.decode(call.result)
+	}
+	return nil
+}
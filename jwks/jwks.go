@@ -0,0 +1,110 @@
+// Package jwks fetches, caches and rotates JSON Web Key Sets served by an
+// issuer, using the go-http-client for retrieval.
+package jwks
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+var (
+	// ErrKeyNotFound is returned when no key matches the requested key id
+	ErrKeyNotFound = errors.New("jwks key not found")
+	// ErrAlgorithmMismatch is returned when the alg of a key isn't the
+	// algorithm it is asked for
+	ErrAlgorithmMismatch = errors.New("jwks key algorithm mismatch")
+)
+
+// JSONWebKey is a single key of a JWK set
+type JSONWebKey struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid,omitempty"`
+	Use       string `json:"use,omitempty"`
+	Algorithm string `json:"alg,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	X         string `json:"x,omitempty"`
+	Y         string `json:"y,omitempty"`
+	K         string `json:"k,omitempty"`
+}
+
+// JSONWebKeySet is a JWK set document as served by the issuer
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// Lookup returns the key with the given kid. An empty kid matches the only
+// key of a single key set
+func (s JSONWebKeySet) Lookup(kid string) (JSONWebKey, error) {
+	for _, k := range s.Keys {
+		if k.KeyID == kid {
+			return k, nil
+		}
+	}
+	if kid == "" && len(s.Keys) == 1 {
+		return s.Keys[0], nil
+	}
+	return JSONWebKey{}, ErrKeyNotFound
+}
+
+// PublicKey returns the crypto key represented by the JWK: *rsa.PublicKey,
+// *ecdsa.PublicKey or []byte for symmetric keys
+func (k JSONWebKey) PublicKey() (interface{}, error) {
+	switch k.KeyType {
+	case "RSA":
+		n, err := decodeInt(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RSA modulus for key %q: %w", k.KeyID, err)
+		}
+		e, err := decodeInt(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RSA exponent for key %q: %w", k.KeyID, err)
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Curve {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q for key %q", k.Curve, k.KeyID)
+		}
+		x, err := decodeInt(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("invalid EC x coordinate for key %q: %w", k.KeyID, err)
+		}
+		y, err := decodeInt(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("invalid EC y coordinate for key %q: %w", k.KeyID, err)
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "oct":
+		secret, err := base64.RawURLEncoding.DecodeString(k.K)
+		if err != nil {
+			return nil, fmt.Errorf("invalid secret for key %q: %w", k.KeyID, err)
+		}
+		return secret, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q for key %q", k.KeyType, k.KeyID)
+}
+
+func decodeInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, fmt.Errorf("empty value")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
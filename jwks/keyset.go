@@ -0,0 +1,338 @@
+package jwks
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	cl "github.com/Traumeel/go-http-client"
+)
+
+type Option func(*KeySet)
+
+// WithTTL set how long a fetched key set is used when the issuer response
+// carries no Cache-Control max-age
+func WithTTL(ttl time.Duration) Option {
+	return func(s *KeySet) {
+		s.ttl = ttl
+	}
+}
+
+// WithMinRefreshInterval limit how often an unknown kid may trigger a refetch
+func WithMinRefreshInterval(d time.Duration) Option {
+	return func(s *KeySet) {
+		s.minRefresh = d
+	}
+}
+
+// WithRotationGrace keep keys removed from the issuer set usable for d after
+// a rotation, so payloads signed shortly before the rotation still verify
+func WithRotationGrace(d time.Duration) Option {
+	return func(s *KeySet) {
+		s.grace = d
+	}
+}
+
+// WithClock set the time source, e.g. a fake clock in tests
+func WithClock(now func() time.Time) Option {
+	return func(s *KeySet) {
+		s.now = now
+	}
+}
+
+// WithRequestOptions add request options to the key set fetch
+func WithRequestOptions(fn ...cl.RequestOption) Option {
+	return func(s *KeySet) {
+		s.requestOptions = append(s.requestOptions, fn...)
+	}
+}
+
+type cachedKey struct {
+	jwk       JSONWebKey
+	key       interface{}
+	retiredAt time.Time
+}
+
+// KeySet is a cached view of the JWK set published by an issuer. Keys are
+// fetched lazily, refreshed when the set expires and when an unknown kid is
+// requested. If a refresh fails the previous keys keep being served. Lookups
+// don't wait on each other while the set is fetched, concurrent refreshes
+// share a single fetch which isn't cancelled with the context of the lookup
+// starting it, it is bounded by the client timeout
+type KeySet struct {
+	client         *cl.Client
+	path           string
+	requestOptions []cl.RequestOption
+	ttl            time.Duration
+	minRefresh     time.Duration
+	grace          time.Duration
+	now            func() time.Time
+
+	mu        sync.Mutex
+	keys      map[string]*cachedKey
+	fetchedAt time.Time
+	expiresAt time.Time
+	// fetching is the fetch in progress, nil when none
+	fetching *fetchCall
+}
+
+// fetchCall is a key set fetch shared by the concurrent refreshes
+type fetchCall struct {
+	done chan struct{}
+	err  error
+}
+
+// NewKeySet fetch keys from path using the issuer client
+func NewKeySet(client *cl.Client, path string, options ...Option) *KeySet {
+	s := &KeySet{
+		client:     client,
+		path:       path,
+		ttl:        time.Hour,
+		minRefresh: 30 * time.Second,
+		now:        time.Now,
+	}
+
+	for _, opt := range options {
+		opt(s)
+	}
+
+	return s
+}
+
+// Lookup returns the JWK with the given kid
+func (s *KeySet) Lookup(ctx context.Context, kid string) (JSONWebKey, error) {
+	k, err := s.lookup(ctx, kid)
+	if err != nil {
+		return JSONWebKey{}, err
+	}
+	return k.jwk, nil
+}
+
+// Key returns the crypto key for the given kid, see JSONWebKey.PublicKey
+func (s *KeySet) Key(ctx context.Context, kid string) (interface{}, error) {
+	k, err := s.lookup(ctx, kid)
+	if err != nil {
+		return nil, err
+	}
+	return k.key, nil
+}
+
+// KeyFor returns the crypto key for the given kid like Key, to verify a
+// signature made with alg. A key whose JWK alg is set to another algorithm
+// is refused with ErrAlgorithmMismatch
+func (s *KeySet) KeyFor(ctx context.Context, kid, alg string) (interface{}, error) {
+	k, err := s.lookup(ctx, kid)
+	if err != nil {
+		return nil, err
+	}
+	if k.jwk.Algorithm != "" && k.jwk.Algorithm != alg {
+		return nil, fmt.Errorf("%w: key %q is for %v, not %v", ErrAlgorithmMismatch, kid, k.jwk.Algorithm, alg)
+	}
+	return k.key, nil
+}
+
+// Keys returns the currently valid keys, fetching them if needed
+func (s *KeySet) Keys(ctx context.Context) (JSONWebKeySet, error) {
+	if err := s.ensureFresh(ctx); err != nil {
+		return JSONWebKeySet{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	set := JSONWebKeySet{Keys: make([]JSONWebKey, 0, len(s.keys))}
+	for _, k := range s.keys {
+		set.Keys = append(set.Keys, k.jwk)
+	}
+	return set, nil
+}
+
+// Refresh fetches the key set from the issuer unconditionally
+func (s *KeySet) Refresh(ctx context.Context) error {
+	return s.refresh(ctx)
+}
+
+func (s *KeySet) lookup(ctx context.Context, kid string) (*cachedKey, error) {
+	if err := s.ensureFresh(ctx); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	k, err := s.find(kid)
+	stale := err == ErrKeyNotFound && s.now().Sub(s.fetchedAt) > s.minRefresh
+	s.mu.Unlock()
+	if !stale {
+		return k, err
+	}
+
+	// the issuer may have rotated keys since the last fetch
+	if err := s.refresh(ctx); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.find(kid)
+}
+
+func (s *KeySet) ensureFresh(ctx context.Context) error {
+	s.mu.Lock()
+	fresh := s.keys != nil && s.now().Before(s.expiresAt)
+	s.mu.Unlock()
+	if fresh {
+		return nil
+	}
+
+	err := s.refresh(ctx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil && ctx.Err() != nil {
+		// the caller gave up, the shared fetch goes on for the others
+		return err
+	}
+	if err != nil && s.keys == nil {
+		return err
+	}
+	if err != nil {
+		// serve the stale keys and back off instead of hitting a failing
+		// issuer on every lookup
+		s.expiresAt = s.now().Add(s.minRefresh)
+	}
+	return nil
+}
+
+func (s *KeySet) find(kid string) (*cachedKey, error) {
+	now := s.now()
+	if k, ok := s.keys[kid]; ok && (k.retiredAt.IsZero() || now.Sub(k.retiredAt) < s.grace) {
+		return k, nil
+	}
+
+	if kid == "" {
+		var found *cachedKey
+		for _, k := range s.keys {
+			if !k.retiredAt.IsZero() {
+				continue
+			}
+			if found != nil {
+				return nil, ErrKeyNotFound
+			}
+			found = k
+		}
+		if found != nil {
+			return found, nil
+		}
+	}
+	return nil, ErrKeyNotFound
+}
+
+// refresh fetch the key set without holding the lock, or join the fetch
+// already in progress, and wait for the keys to be swapped in or ctx to be
+// done. The fetch runs with the values of ctx but not its cancellation, so
+// a caller giving up doesn't fail the others
+func (s *KeySet) refresh(ctx context.Context) error {
+	s.mu.Lock()
+	call := s.fetching
+	if call == nil {
+		call = &fetchCall{done: make(chan struct{})}
+		s.fetching = call
+		go s.run(detachedContext{ctx}, call)
+	}
+	s.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run fetch the key set of call and swap it in
+func (s *KeySet) run(ctx context.Context, call *fetchCall) {
+	set, header, err := s.fetch(ctx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err == nil {
+		s.swap(set, header)
+	}
+	call.err = err
+	s.fetching = nil
+	close(call.done)
+}
+
+// detachedContext keeps the values of a context without its deadline and
+// cancellation
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
+
+func (s *KeySet) fetch(ctx context.Context) (JSONWebKeySet, http.Header, error) {
+	set := JSONWebKeySet{}
+	var header http.Header
+	captureHeader := func(resp *http.Response) error {
+		header = resp.Header
+		return cl.JsonParser(&set)(resp)
+	}
+
+	if err := s.client.DoRequest(ctx, http.MethodGet, s.path, captureHeader, s.requestOptions...); err != nil {
+		return set, nil, fmt.Errorf("failed to fetch jwks: %w", err)
+	}
+	return set, header, nil
+}
+
+// swap replace the cached keys with set, s.mu must be held
+func (s *KeySet) swap(set JSONWebKeySet, header http.Header) {
+	now := s.now()
+	keys := make(map[string]*cachedKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		// keys of unsupported types can't verify anything, skip them
+		// instead of rejecting the whole set
+		key, err := jwk.PublicKey()
+		if err != nil {
+			continue
+		}
+		keys[jwk.KeyID] = &cachedKey{jwk: jwk, key: key}
+	}
+
+	if s.grace > 0 {
+		for kid, k := range s.keys {
+			if _, ok := keys[kid]; ok {
+				continue
+			}
+			if k.retiredAt.IsZero() {
+				k.retiredAt = now
+			}
+			if now.Sub(k.retiredAt) < s.grace {
+				keys[kid] = k
+			}
+		}
+	}
+
+	s.keys = keys
+	s.fetchedAt = now
+	s.expiresAt = now.Add(maxAge(header, s.ttl))
+}
+
+func maxAge(header http.Header, fallback time.Duration) time.Duration {
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		directive = strings.TrimSpace(directive)
+		if !strings.HasPrefix(directive, "max-age=") {
+			continue
+		}
+		if seconds, err := strconv.Atoi(strings.TrimPrefix(directive, "max-age=")); err == nil && seconds > 0 {
+			return time.Duration(seconds) * time.Second
+		}
+	}
+	return fallback
+}
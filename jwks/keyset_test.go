@@ -0,0 +1,110 @@
+package jwks
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	cl "github.com/Traumeel/go-http-client"
+)
+
+const testKeySet = `{"keys":[{"kty":"oct","kid":"k1","alg":"HS256","k":"c2VjcmV0"}]}`
+
+// issuer serves testKeySet once release is closed and counts the fetches,
+// reached is closed when the first fetch arrives
+type issuer struct {
+	*httptest.Server
+	fetches int32
+	reached chan struct{}
+	release chan struct{}
+}
+
+func blockingIssuer(t *testing.T) *issuer {
+	is := &issuer{reached: make(chan struct{}), release: make(chan struct{})}
+	var once sync.Once
+	is.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&is.fetches, 1)
+		once.Do(func() { close(is.reached) })
+		<-is.release
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(testKeySet))
+	}))
+	t.Cleanup(is.Close)
+	return is
+}
+
+func TestConcurrentLookupsShareFetch(t *testing.T) {
+	is := blockingIssuer(t)
+	s := NewKeySet(cl.NewClient(is.URL), "/jwks")
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := s.Key(context.Background(), "k1")
+			errs <- err
+		}()
+	}
+	close(is.release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := atomic.LoadInt32(&is.fetches); n != 1 {
+		t.Fatalf("fetched %v times, want 1", n)
+	}
+}
+
+func TestCancelledLookupDoesntFailOthers(t *testing.T) {
+	is := blockingIssuer(t)
+	s := NewKeySet(cl.NewClient(is.URL), "/jwks")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error)
+	go func() {
+		_, err := s.Key(ctx, "k1")
+		first <- err
+	}()
+	<-is.reached
+
+	second := make(chan error)
+	go func() {
+		_, err := s.Key(context.Background(), "k1")
+		second <- err
+	}()
+
+	cancel()
+	if err := <-first; !errors.Is(err, context.Canceled) {
+		t.Fatalf("first lookup err = %v, want context.Canceled", err)
+	}
+	close(is.release)
+	if err := <-second; err != nil {
+		t.Fatalf("second lookup err = %v", err)
+	}
+	if n := atomic.LoadInt32(&is.fetches); n != 1 {
+		t.Fatalf("fetched %v times, want 1", n)
+	}
+}
+
+func TestKeyForAlgorithm(t *testing.T) {
+	is := blockingIssuer(t)
+	close(is.release)
+	s := NewKeySet(cl.NewClient(is.URL), "/jwks")
+
+	if _, err := s.KeyFor(context.Background(), "k1", "HS256"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.KeyFor(context.Background(), "k1", "RS256"); !errors.Is(err, ErrAlgorithmMismatch) {
+		t.Fatalf("err = %v, want ErrAlgorithmMismatch", err)
+	}
+}
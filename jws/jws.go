@@ -0,0 +1,314 @@
+// Package jws verifies JWS signed response payloads, both in the compact
+// serialization (application/jose) and as detached signatures (RFC 7515
+// appendix F, optionally with an unencoded payload as per RFC 7797).
+package jws
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/Traumeel/go-http-client/jwks"
+)
+
+var (
+	// ErrMalformed is returned when the signature can't be decoded
+	ErrMalformed = errors.New("malformed jws")
+	// ErrMissingSignature is returned when the response carries no signature
+	ErrMissingSignature = errors.New("missing jws signature")
+	// ErrUnsupportedAlgorithm is returned for unknown or disallowed algorithms
+	ErrUnsupportedAlgorithm = errors.New("unsupported jws algorithm")
+	// ErrKeyNotFound is returned when no key matches the signature key id
+	ErrKeyNotFound = jwks.ErrKeyNotFound
+	// ErrAlgorithmMismatch is returned when the key isn't meant for the
+	// signature algorithm
+	ErrAlgorithmMismatch = jwks.ErrAlgorithmMismatch
+	// ErrInvalidSignature is returned when the signature doesn't match the payload
+	ErrInvalidSignature = errors.New("invalid jws signature")
+)
+
+// VerificationError represents a failed signature verification
+type VerificationError struct {
+	Reason    error
+	KeyID     string
+	Algorithm string
+}
+
+func (t VerificationError) Error() string {
+	return fmt.Sprintf("jws verification error: %v | kid: %v | alg: %v", t.Reason, t.KeyID, t.Algorithm)
+}
+
+func (t VerificationError) Unwrap() error {
+	return t.Reason
+}
+
+// KeySource resolves the verification key for a key id. The returned key is
+// an *rsa.PublicKey, *ecdsa.PublicKey or []byte for HMAC algorithms.
+// *jwks.KeySet implements it for keys fetched from the issuer
+type KeySource interface {
+	Key(ctx context.Context, kid string) (interface{}, error)
+}
+
+// AlgorithmKeySource is a KeySource which checks the key is meant for the
+// algorithm of the signature, as *jwks.KeySet does with the JWK alg. The
+// Verifier looks keys up with KeyFor when the source implements it
+type AlgorithmKeySource interface {
+	KeySource
+	KeyFor(ctx context.Context, kid, alg string) (interface{}, error)
+}
+
+// Header holds the protected JOSE header fields the verifier understands
+type Header struct {
+	Algorithm string   `json:"alg"`
+	KeyID     string   `json:"kid,omitempty"`
+	Type      string   `json:"typ,omitempty"`
+	Critical  []string `json:"crit,omitempty"`
+	B64       *bool    `json:"b64,omitempty"`
+}
+
+type VerifierOption func(*Verifier)
+
+// WithDetachedHeader set the response header carrying a detached signature
+func WithDetachedHeader(name string) VerifierOption {
+	return func(v *Verifier) {
+		v.detachedHeader = name
+	}
+}
+
+// WithAlgorithms restrict the accepted signature algorithms
+func WithAlgorithms(algs ...string) VerifierOption {
+	return func(v *Verifier) {
+		v.algorithms = make(map[string]bool, len(algs))
+		for _, alg := range algs {
+			v.algorithms[alg] = true
+		}
+	}
+}
+
+// WithCriticalHeaders set header parameters the caller understands and
+// accepts in the crit header besides b64
+func WithCriticalHeaders(names ...string) VerifierOption {
+	return func(v *Verifier) {
+		for _, name := range names {
+			v.critical[name] = true
+		}
+	}
+}
+
+// Verifier checks JWS signatures against keys from a KeySource
+type Verifier struct {
+	keys           KeySource
+	detachedHeader string
+	algorithms     map[string]bool
+	critical       map[string]bool
+}
+
+func NewVerifier(keys KeySource, options ...VerifierOption) *Verifier {
+	v := &Verifier{
+		keys:     keys,
+		critical: map[string]bool{"b64": true},
+	}
+
+	for _, opt := range options {
+		opt(v)
+	}
+
+	return v
+}
+
+// Verify checks a compact serialized JWS and returns its payload
+func (v *Verifier) Verify(ctx context.Context, token []byte) ([]byte, *Header, error) {
+	parts := bytes.Split(bytes.TrimSpace(token), []byte("."))
+	if len(parts) != 3 {
+		return nil, nil, VerificationError{Reason: ErrMalformed}
+	}
+
+	h, err := v.parseHeader(parts[0])
+	if err != nil {
+		return nil, h, err
+	}
+
+	if err := v.verify(ctx, h, parts[0], parts[1], parts[2]); err != nil {
+		return nil, h, err
+	}
+
+	if h.B64 != nil && !*h.B64 {
+		return parts[1], h, nil
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(string(parts[1]))
+	if err != nil {
+		return nil, h, VerificationError{Reason: ErrMalformed, KeyID: h.KeyID, Algorithm: h.Algorithm}
+	}
+	return payload, h, nil
+}
+
+// VerifyDetached checks a detached JWS (header..signature) against payload
+func (v *Verifier) VerifyDetached(ctx context.Context, token, payload []byte) (*Header, error) {
+	parts := bytes.Split(bytes.TrimSpace(token), []byte("."))
+	if len(parts) != 3 || len(parts[1]) != 0 {
+		return nil, VerificationError{Reason: ErrMalformed}
+	}
+
+	h, err := v.parseHeader(parts[0])
+	if err != nil {
+		return nil, err
+	}
+
+	encoded := payload
+	if h.B64 == nil || *h.B64 {
+		encoded = []byte(base64.RawURLEncoding.EncodeToString(payload))
+	}
+	return h, v.verify(ctx, h, parts[0], encoded, parts[2])
+}
+
+func (v *Verifier) parseHeader(raw []byte) (*Header, error) {
+	data, err := base64.RawURLEncoding.DecodeString(string(raw))
+	if err != nil {
+		return nil, VerificationError{Reason: ErrMalformed}
+	}
+
+	h := &Header{}
+	if err := json.Unmarshal(data, h); err != nil {
+		return nil, VerificationError{Reason: ErrMalformed}
+	}
+
+	critical := false
+	for _, name := range h.Critical {
+		if !v.critical[name] {
+			return h, VerificationError{
+				Reason:    fmt.Errorf("%w: unknown critical header %q", ErrMalformed, name),
+				KeyID:     h.KeyID,
+				Algorithm: h.Algorithm,
+			}
+		}
+		critical = critical || name == "b64"
+	}
+	// RFC 7797 section 6, b64 must be understood by the recipient
+	if h.B64 != nil && !critical {
+		return h, VerificationError{
+			Reason:    fmt.Errorf("%w: b64 header not listed in crit", ErrMalformed),
+			KeyID:     h.KeyID,
+			Algorithm: h.Algorithm,
+		}
+	}
+
+	return h, nil
+}
+
+func (v *Verifier) verify(ctx context.Context, h *Header, header, payload, signature []byte) error {
+	fail := func(reason error) error {
+		return VerificationError{Reason: reason, KeyID: h.KeyID, Algorithm: h.Algorithm}
+	}
+
+	if v.algorithms != nil && !v.algorithms[h.Algorithm] {
+		return fail(ErrUnsupportedAlgorithm)
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(string(signature))
+	if err != nil {
+		return fail(ErrMalformed)
+	}
+
+	var key interface{}
+	if keys, ok := v.keys.(AlgorithmKeySource); ok {
+		key, err = keys.KeyFor(ctx, h.KeyID, h.Algorithm)
+	} else {
+		key, err = v.keys.Key(ctx, h.KeyID)
+	}
+	if err != nil {
+		return fail(err)
+	}
+
+	input := make([]byte, 0, len(header)+len(payload)+1)
+	input = append(append(append(input, header...), '.'), payload...)
+	if err := verifySignature(h.Algorithm, key, input, sig); err != nil {
+		return fail(err)
+	}
+
+	return nil
+}
+
+func hashFor(alg string) (crypto.Hash, bool) {
+	if len(alg) != 5 {
+		return 0, false
+	}
+	switch alg[2:] {
+	case "256":
+		return crypto.SHA256, true
+	case "384":
+		return crypto.SHA384, true
+	case "512":
+		return crypto.SHA512, true
+	}
+	return 0, false
+}
+
+func verifySignature(alg string, key interface{}, input, sig []byte) error {
+	hash, ok := hashFor(alg)
+	if !ok {
+		return ErrUnsupportedAlgorithm
+	}
+
+	if alg[:2] == "HS" {
+		secret, ok := key.([]byte)
+		if !ok {
+			return fmt.Errorf("%w: %T key for %v", ErrUnsupportedAlgorithm, key, alg)
+		}
+		mac := hmac.New(hash.New, secret)
+		mac.Write(input)
+		if !hmac.Equal(mac.Sum(nil), sig) {
+			return ErrInvalidSignature
+		}
+		return nil
+	}
+
+	hasher := hash.New()
+	hasher.Write(input)
+	digest := hasher.Sum(nil)
+
+	switch alg[:2] {
+	case "RS", "PS":
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("%w: %T key for %v", ErrUnsupportedAlgorithm, key, alg)
+		}
+		var err error
+		if alg[:2] == "RS" {
+			err = rsa.VerifyPKCS1v15(pub, hash, digest, sig)
+		} else {
+			err = rsa.VerifyPSS(pub, hash, digest, sig, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		}
+		if err != nil {
+			return ErrInvalidSignature
+		}
+		return nil
+	case "ES":
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("%w: %T key for %v", ErrUnsupportedAlgorithm, key, alg)
+		}
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if len(sig) != 2*size {
+			return ErrInvalidSignature
+		}
+		r := new(big.Int).SetBytes(sig[:size])
+		s := new(big.Int).SetBytes(sig[size:])
+		if !ecdsa.Verify(pub, digest, r, s) {
+			return ErrInvalidSignature
+		}
+		return nil
+	}
+
+	return ErrUnsupportedAlgorithm
+}
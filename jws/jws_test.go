@@ -0,0 +1,81 @@
+package jws
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	cl "github.com/Traumeel/go-http-client"
+)
+
+var testKey = []byte("secret")
+
+// sign returns the HS256 compact serialization of payload with header,
+// payload is left unencoded when raw is set
+func sign(header string, payload []byte, raw bool) []byte {
+	encodedHeader := base64.RawURLEncoding.EncodeToString([]byte(header))
+	encodedPayload := string(payload)
+	if !raw {
+		encodedPayload = base64.RawURLEncoding.EncodeToString(payload)
+	}
+	mac := hmac.New(sha256.New, testKey)
+	mac.Write([]byte(encodedHeader + "." + encodedPayload))
+	return []byte(encodedHeader + "." + encodedPayload + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)))
+}
+
+// contextKeys records the context keys are looked up with
+type contextKeys struct {
+	StaticKeys
+	ctx context.Context
+}
+
+func (k *contextKeys) Key(ctx context.Context, kid string) (interface{}, error) {
+	k.ctx = ctx
+	return k.StaticKeys.Key(ctx, kid)
+}
+
+func TestParserWithoutRequest(t *testing.T) {
+	keys := &contextKeys{StaticKeys: StaticKeys{"k1": testKey}}
+	token := sign(`{"alg":"HS256","kid":"k1"}`, []byte(`{"id":42}`), false)
+	resp := &http.Response{
+		Header: http.Header{"Content-Type": {ContentType}},
+		Body:   ioutil.NopCloser(bytes.NewReader(token)),
+	}
+
+	var out struct{ ID int }
+	if err := Parser(NewVerifier(keys), cl.JsonParser(&out))(resp); err != nil {
+		t.Fatal(err)
+	}
+	if out.ID != 42 {
+		t.Errorf("out = %+v", out)
+	}
+	if keys.ctx == nil {
+		t.Error("keys looked up without a context")
+	}
+}
+
+func TestUnencodedPayloadRequiresCrit(t *testing.T) {
+	v := NewVerifier(StaticKeys{"k1": testKey})
+	payload := []byte("$02 unencoded")
+
+	if _, err := v.VerifyDetached(context.Background(), detach(sign(`{"alg":"HS256","kid":"k1","b64":false,"crit":["b64"]}`, payload, true)), payload); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := v.VerifyDetached(context.Background(), detach(sign(`{"alg":"HS256","kid":"k1","b64":false}`, payload, true)), payload)
+	if !errors.Is(err, ErrMalformed) {
+		t.Fatalf("err = %v, want ErrMalformed", err)
+	}
+}
+
+// detach drop the payload of a compact serialization
+func detach(token []byte) []byte {
+	parts := bytes.Split(token, []byte("."))
+	return bytes.Join([][]byte{parts[0], nil, parts[2]}, []byte("."))
+}
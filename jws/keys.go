@@ -0,0 +1,25 @@
+package jws
+
+import (
+	"context"
+)
+
+// StaticKeys is a KeySource backed by a fixed kid to key map
+type StaticKeys map[string]interface{}
+
+func (s StaticKeys) Key(_ context.Context, kid string) (interface{}, error) {
+	return lookup(s, kid)
+}
+
+func lookup(keys map[string]interface{}, kid string) (interface{}, error) {
+	if key, ok := keys[kid]; ok {
+		return key, nil
+	}
+	// a signature without kid is acceptable only when there is no ambiguity
+	if kid == "" && len(keys) == 1 {
+		for _, key := range keys {
+			return key, nil
+		}
+	}
+	return nil, ErrKeyNotFound
+}
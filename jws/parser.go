@@ -0,0 +1,56 @@
+package jws
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+
+	cl "github.com/Traumeel/go-http-client"
+)
+
+// ContentType is the media type of a compact serialized JWS
+const ContentType = "application/jose"
+
+// Parser verifies the signed response and hands the verified payload to
+// next. Responses with the application/jose content type are treated as
+// compact JWS, otherwise the signature is read from the detached header
+func Parser(v *Verifier, next cl.ResponseParser) cl.ResponseParser {
+	return func(resp *http.Response) (e error) {
+		if resp == nil || v == nil || next == nil {
+			return fmt.Errorf("jws.Parser function error: %v | %v", resp, v)
+		}
+
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("failed to read resp body: %w", err)
+		}
+
+		ctx := context.Background()
+		if resp.Request != nil {
+			ctx = resp.Request.Context()
+		}
+		mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+
+		var payload []byte
+		switch {
+		case mediaType == ContentType:
+			if payload, _, err = v.Verify(ctx, body); err != nil {
+				return err
+			}
+		case v.detachedHeader != "" && resp.Header.Get(v.detachedHeader) != "":
+			if _, err = v.VerifyDetached(ctx, []byte(resp.Header.Get(v.detachedHeader)), body); err != nil {
+				return err
+			}
+			payload = body
+		default:
+			return VerificationError{Reason: ErrMissingSignature}
+		}
+
+		resp.Body = ioutil.NopCloser(bytes.NewReader(payload))
+		resp.ContentLength = int64(len(payload))
+		return next(resp)
+	}
+}
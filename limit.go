@@ -0,0 +1,141 @@
+package go_http_client
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// ResponseTooLargeError is returned by reads of a response body past the
+// limit set with WithMaxResponseBytes
+type ResponseTooLargeError struct {
+	Limit int64
+	URL   string
+}
+
+func (t ResponseTooLargeError) Error() string {
+	return fmt.Sprintf("response body of %v exceeds %v bytes", t.URL, t.Limit)
+}
+
+// ErrDecompressionLimit is returned by reads of a compressed response body
+// which expands past the limit set with WithMaxDecompressedBytes
+var ErrDecompressionLimit = errors.New("decompressed response body exceeds the limit")
+
+// WithMaxResponseBytes fail reading response bodies after n bytes with a
+// ResponseTooLargeError, for the parsers, the response validator and
+// streamed bodies alike. The limit applies to the decompressed body, 0
+// disables it
+func WithMaxResponseBytes(n int64) Option {
+	return func(c *Client) {
+		c.maxResponseBytes = n
+	}
+}
+
+// WithMaxDecompressedBytes fail reading compressed response bodies once they
+// expanded to n bytes, whether net/http or the client decompressed them. It
+// guards against small payloads that inflate to gigabytes, 0 disables it
+func WithMaxDecompressedBytes(n int64) Option {
+	return func(c *Client) {
+		c.maxDecompressedBytes = n
+	}
+}
+
+// DefaultMaxErrorBodyBytes is the default amount of an error response body
+// kept in the StatusCodeError, see WithMaxErrorBodyBytes
+const DefaultMaxErrorBodyBytes = 64 << 10
+
+// errorBodyTruncated marks a StatusCodeError body cut at the limit
+const errorBodyTruncated = "... [truncated]"
+
+// WithMaxErrorBodyBytes set how much of an error response body
+// ResponseValidator reads into the StatusCodeError, DefaultMaxErrorBodyBytes
+// by default. Longer bodies are cut and marked as truncated, 0 disables the
+// limit
+func WithMaxErrorBodyBytes(n int64) Option {
+	return func(c *Client) {
+		c.maxErrorBodyBytes = n
+	}
+}
+
+type errorBodyLimitKey struct{}
+
+// errorBodyLimit returns the error body limit of the request of resp
+func errorBodyLimit(resp *http.Response) int64 {
+	if resp.Request != nil {
+		if n, ok := resp.Request.Context().Value(errorBodyLimitKey{}).(int64); ok {
+			return n
+		}
+	}
+	return DefaultMaxErrorBodyBytes
+}
+
+// readErrorBody read the body up to the error body limit, reporting whether
+// it was cut
+func readErrorBody(resp *http.Response) ([]byte, bool, error) {
+	limit := errorBodyLimit(resp)
+	if limit <= 0 {
+		body, err := ioutil.ReadAll(resp.Body)
+		return body, false, err
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if int64(len(body)) > limit {
+		return body[:limit], true, err
+	}
+	return body, false, err
+}
+
+type limitedBody struct {
+	body      io.ReadCloser
+	remaining int64
+	err       error
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining <= 0 {
+		// peek a byte to tell an exact fit from an oversized body
+		n, err := b.body.Read(make([]byte, 1))
+		if n > 0 {
+			return 0, b.err
+		}
+		return 0, err
+	}
+
+	if int64(len(p)) > b.remaining {
+		p = p[:b.remaining]
+	}
+	n, err := b.body.Read(p)
+	b.remaining -= int64(n)
+	return n, err
+}
+
+func (b *limitedBody) Close() error {
+	return b.body.Close()
+}
+
+func (c *Client) limitBody(req *http.Request, resp *http.Response) {
+	if resp.Uncompressed && c.maxDecompressedBytes > 0 {
+		resp.Body = &limitedBody{
+			body:      resp.Body,
+			remaining: c.maxDecompressedBytes,
+			err:       fmt.Errorf("%w: %v bytes from %v", ErrDecompressionLimit, c.maxDecompressedBytes, RedactedURL(req)),
+		}
+	}
+
+	if c.maxResponseBytes <= 0 {
+		return
+	}
+
+	remaining := c.maxResponseBytes
+	if resp.ContentLength > remaining {
+		// known to be too large, fail on the first read
+		remaining = 0
+	}
+	resp.Body = &limitedBody{
+		body:      resp.Body,
+		remaining: remaining,
+		err:       ResponseTooLargeError{Limit: c.maxResponseBytes, URL: RedactedURL(req)},
+	}
+}
@@ -0,0 +1,92 @@
+package go_http_client
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Fields are structured log fields
+type Fields map[string]interface{}
+
+// Logger is the logging interface of the client. Adapters for logrus, slog,
+// zap and zerolog are available, see WithLog
+type Logger interface {
+	// WithFields returns a logger adding fields to its entries
+	WithFields(fields Fields) Logger
+	Debugf(format string, args ...interface{})
+	Infof(format string, args ...interface{})
+	Warnf(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+}
+
+// NewStdLogger returns a Logger writing to l in a logfmt like format, debug
+// entries are dropped unless debug is set. A nil l writes to stderr
+func NewStdLogger(l *log.Logger, debug bool) Logger {
+	if l == nil {
+		l = log.New(os.Stderr, "", log.LstdFlags)
+	}
+	return &stdLogger{l: l, debug: debug}
+}
+
+type stdLogger struct {
+	l      *log.Logger
+	debug  bool
+	fields Fields
+}
+
+func (s *stdLogger) WithFields(fields Fields) Logger {
+	merged := make(Fields, len(s.fields)+len(fields))
+	for k, v := range s.fields {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	return &stdLogger{l: s.l, debug: s.debug, fields: merged}
+}
+
+func (s *stdLogger) Debugf(format string, args ...interface{}) {
+	if s.debug {
+		s.output("debug", format, args)
+	}
+}
+
+func (s *stdLogger) Infof(format string, args ...interface{}) {
+	s.output("info", format, args)
+}
+
+func (s *stdLogger) Warnf(format string, args ...interface{}) {
+	s.output("warning", format, args)
+}
+
+func (s *stdLogger) Errorf(format string, args ...interface{}) {
+	s.output("error", format, args)
+}
+
+func (s *stdLogger) output(level, format string, args []interface{}) {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "level=%v msg=%q", level, fmt.Sprintf(format, args...))
+
+	keys := make([]string, 0, len(s.fields))
+	for k := range s.fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v := fmt.Sprint(s.fields[k])
+		if v == "" || strings.ContainsAny(v, " \"=\\\n") {
+			v = strconv.Quote(v)
+		}
+		fmt.Fprintf(b, " %v=%v", k, v)
+	}
+	s.l.Output(3, b.String())
+}
+
+// withError returns l adding err as the "error" field
+func withError(l Logger, err error) Logger {
+	return l.WithFields(Fields{"error": err})
+}
@@ -0,0 +1,129 @@
+package go_http_client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// logEntry is an entry written to a recordingLogger
+type logEntry struct {
+	level  string
+	msg    string
+	fields Fields
+}
+
+// recordingLogger keeps the entries written through it and its children
+type recordingLogger struct {
+	mu      *sync.Mutex
+	entries *[]logEntry
+	fields  Fields
+}
+
+func newRecordingLogger() *recordingLogger {
+	return &recordingLogger{mu: &sync.Mutex{}, entries: &[]logEntry{}}
+}
+
+func (l *recordingLogger) WithFields(fields Fields) Logger {
+	merged := make(Fields, len(l.fields)+len(fields))
+	for k, v := range l.fields {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	return &recordingLogger{mu: l.mu, entries: l.entries, fields: merged}
+}
+
+func (l *recordingLogger) Debugf(format string, args ...interface{}) { l.add("debug", format, args) }
+func (l *recordingLogger) Infof(format string, args ...interface{})  { l.add("info", format, args) }
+func (l *recordingLogger) Warnf(format string, args ...interface{})  { l.add("warning", format, args) }
+func (l *recordingLogger) Errorf(format string, args ...interface{}) { l.add("error", format, args) }
+
+func (l *recordingLogger) add(level, format string, args []interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	*l.entries = append(*l.entries, logEntry{level: level, msg: fmt.Sprintf(format, args...), fields: l.fields})
+}
+
+func (l *recordingLogger) logged() []logEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]logEntry(nil), *l.entries...)
+}
+
+func TestDebugLogLevels(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			http.Error(w, "boom", http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte("pong"))
+	}))
+	defer srv.Close()
+
+	log := newRecordingLogger()
+	c := NewClient(srv.URL, WithLog(log), WithDebug(true))
+
+	var out string
+	if err := c.DoRequestString(context.Background(), http.MethodGet, "/ping", &out); err != nil {
+		t.Fatal(err)
+	}
+	levels := map[string][]string{}
+	for _, e := range log.logged() {
+		levels[e.level] = append(levels[e.level], e.msg)
+	}
+	if len(levels["debug"]) != 2 || !strings.HasPrefix(levels["debug"][0], "GET /ping") || !strings.Contains(levels["debug"][1], "pong") {
+		t.Errorf("debug entries = %q, want the request and response dumps", levels["debug"])
+	}
+	for _, msg := range levels["info"] {
+		if strings.Contains(msg, "\n") {
+			t.Errorf("info entry %q isn't a one line summary", msg)
+		}
+	}
+	if len(levels["warning"]) != 0 || len(levels["error"]) != 0 {
+		t.Errorf("unexpected failures logged: %v", levels)
+	}
+
+	log = newRecordingLogger()
+	c = NewClient(srv.URL, WithLog(log), WithDebug(true))
+	if err := c.Get(context.Background(), "/fail"); err == nil {
+		t.Fatal("want an error")
+	}
+	warned := false
+	for _, e := range log.logged() {
+		if e.level == "warning" && e.fields["error"] != nil {
+			warned = true
+		}
+	}
+	if !warned {
+		t.Errorf("failure not logged at warning level: %v", log.logged())
+	}
+}
+
+func TestNoBodyParserRedaction(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Secret", "hunter2")
+		w.Write([]byte(`{"token":"s3cr3t","id":1}`))
+	}))
+	defer srv.Close()
+
+	log := newRecordingLogger()
+	c := NewClient(srv.URL, WithLog(log), WithJSONRedaction("$.token"), WithRedactedHeaders("X-Secret"))
+	if err := c.DoRequestNoBody(context.Background(), http.MethodDelete, "/users/1"); err != nil {
+		t.Fatal(err)
+	}
+
+	entries := log.logged()
+	if len(entries) != 1 || entries[0].level != "debug" {
+		t.Fatalf("entries = %+v, want a single debug dump", entries)
+	}
+	if strings.Contains(entries[0].msg, "hunter2") || strings.Contains(entries[0].msg, "s3cr3t") {
+		t.Fatalf("secret dumped: %v", entries[0].msg)
+	}
+}
@@ -0,0 +1,43 @@
+// Package logrus adapts a logrus logger to the client Logger interface. It
+// lives in its own module so logrus is only pulled in by the users who need it
+package logrus
+
+import (
+	cl "github.com/Traumeel/go-http-client"
+	"github.com/sirupsen/logrus"
+)
+
+type logger struct {
+	l logrus.FieldLogger
+}
+
+// New returns a Logger writing to l, a *logrus.Logger or *logrus.Entry
+func New(l logrus.FieldLogger) cl.Logger {
+	return logger{l: l}
+}
+
+// WithLog log with the logrus logger l, as the client did before the Logger
+// interface
+func WithLog(l logrus.FieldLogger) cl.Option {
+	return cl.WithLog(New(l))
+}
+
+func (t logger) WithFields(fields cl.Fields) cl.Logger {
+	return logger{l: t.l.WithFields(logrus.Fields(fields))}
+}
+
+func (t logger) Debugf(format string, args ...interface{}) {
+	t.l.Debugf(format, args...)
+}
+
+func (t logger) Infof(format string, args ...interface{}) {
+	t.l.Infof(format, args...)
+}
+
+func (t logger) Warnf(format string, args ...interface{}) {
+	t.l.Warnf(format, args...)
+}
+
+func (t logger) Errorf(format string, args ...interface{}) {
+	t.l.Errorf(format, args...)
+}
@@ -0,0 +1,13 @@
+module github.com/Traumeel/go-http-client/msgpack
+
+go 1.18
+
+require (
+	github.com/Traumeel/go-http-client v0.1.0
+	github.com/vmihailenco/msgpack/v5 v5.4.1
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
+)
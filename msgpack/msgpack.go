@@ -0,0 +1,30 @@
+// Package msgpack adds MessagePack request bodies and response parsers to the
+// client. It lives in its own module so the msgpack library is only pulled in
+// by the users who need it
+package msgpack
+
+import (
+	"io"
+
+	cl "github.com/Traumeel/go-http-client"
+	msgpackv5 "github.com/vmihailenco/msgpack/v5"
+)
+
+// ContentType is the media type of MessagePack bodies, some services use the
+// older application/x-msgpack
+const ContentType = "application/msgpack"
+
+func decode(r io.Reader, v interface{}) error {
+	return msgpackv5.NewDecoder(r).Decode(v)
+}
+
+// Parser decode the MessagePack response into dst
+func Parser(dst interface{}) cl.ResponseParser {
+	return cl.DecodingParser(dst, decode)
+}
+
+// WithBodyOpt marshal v as the MessagePack request body and set the
+// Content-Type
+func WithBodyOpt(v interface{}) cl.RequestOption {
+	return cl.WithEncodedBodyOpt(v, ContentType, msgpackv5.Marshal)
+}
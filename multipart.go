@@ -0,0 +1,342 @@
+package go_http_client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+type multipartPart struct {
+	header textproto.MIMEHeader
+	open   func() (io.ReadCloser, error)
+	// size returns the length of the part content, -1 when unknown
+	size func() int64
+	// once is set for parts backed by a reader which can't be reopened
+	once bool
+}
+
+// MultipartBuilder assembles a multipart/form-data body from fields, files,
+// readers and parts with custom headers
+type MultipartBuilder struct {
+	parts    []multipartPart
+	boundary string
+	err      error
+
+	built       bool
+	contentType string
+	body        []byte
+}
+
+func NewMultipartBuilder() *MultipartBuilder {
+	return &MultipartBuilder{}
+}
+
+// Boundary set a custom boundary instead of a random one
+func (b *MultipartBuilder) Boundary(boundary string) *MultipartBuilder {
+	b.boundary = boundary
+	return b
+}
+
+// Field add a form field
+func (b *MultipartBuilder) Field(name, value string) *MultipartBuilder {
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", formDisposition(name, ""))
+	b.parts = append(b.parts, multipartPart{
+		header: h,
+		open: func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(strings.NewReader(value)), nil
+		},
+		size: func() int64 {
+			return int64(len(value))
+		},
+	})
+	return b
+}
+
+// File add the file at path as a file field, the content type is derived
+// from the file extension
+func (b *MultipartBuilder) File(field, path string) *MultipartBuilder {
+	b.parts = append(b.parts, multipartPart{
+		header: fileHeader(field, filepath.Base(path), ""),
+		open: func() (io.ReadCloser, error) {
+			return os.Open(path)
+		},
+		size: func() int64 {
+			info, err := os.Stat(path)
+			if err != nil {
+				return -1
+			}
+			return info.Size()
+		},
+	})
+	return b
+}
+
+// Stream add a file field whose content is opened by open, once per attempt
+// so streaming uploads can be retried. size is the content length or -1
+func (b *MultipartBuilder) Stream(field, filename string, open func() (io.ReadCloser, error), size int64) *MultipartBuilder {
+	b.parts = append(b.parts, multipartPart{
+		header: fileHeader(field, filename, ""),
+		open:   open,
+		size: func() int64 {
+			return size
+		},
+	})
+	return b
+}
+
+// Reader add the content of r as a file field named filename
+func (b *MultipartBuilder) Reader(field, filename string, r io.Reader) *MultipartBuilder {
+	return b.Part(fileHeader(field, filename, ""), r)
+}
+
+// Part add a part with custom headers
+func (b *MultipartBuilder) Part(header textproto.MIMEHeader, r io.Reader) *MultipartBuilder {
+	b.parts = append(b.parts, multipartPart{
+		header: header,
+		open: func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(r), nil
+		},
+		size: func() int64 {
+			return -1
+		},
+		once: true,
+	})
+	return b
+}
+
+// Build encode the body, readers are consumed so the result is kept and
+// returned by later calls
+func (b *MultipartBuilder) Build() (contentType string, body []byte, err error) {
+	if b.built {
+		return b.contentType, b.body, nil
+	}
+
+	buf := &bytes.Buffer{}
+	contentType, err = b.writeTo(buf)
+	if err != nil {
+		return "", nil, err
+	}
+
+	b.built, b.contentType, b.body = true, contentType, buf.Bytes()
+	return b.contentType, b.body, nil
+}
+
+func (b *MultipartBuilder) writeTo(w io.Writer) (string, error) {
+	mw := multipart.NewWriter(w)
+	if b.boundary != "" {
+		if err := mw.SetBoundary(b.boundary); err != nil {
+			return "", err
+		}
+	}
+
+	for _, p := range b.parts {
+		if err := writePart(mw, p); err != nil {
+			return "", err
+		}
+	}
+
+	if err := mw.Close(); err != nil {
+		return "", err
+	}
+	return mw.FormDataContentType(), nil
+}
+
+func writePart(mw *multipart.Writer, p multipartPart) error {
+	r, err := p.open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	pw, err := mw.CreatePart(p.header)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(pw, r)
+	return err
+}
+
+func (b *MultipartBuilder) ensureBoundary() {
+	if b.boundary == "" {
+		b.boundary = multipart.NewWriter(ioutil.Discard).Boundary()
+	}
+}
+
+// contentLength returns the encoded body size, -1 when a part size is unknown
+func (b *MultipartBuilder) contentLength() int64 {
+	total := int64(0)
+	skeleton := &MultipartBuilder{boundary: b.boundary}
+	for _, p := range b.parts {
+		size := p.size()
+		if size < 0 {
+			return -1
+		}
+		total += size
+		skeleton.Part(p.header, strings.NewReader(""))
+	}
+
+	counter := &countingWriter{}
+	if _, err := skeleton.writeTo(counter); err != nil {
+		return -1
+	}
+	return total + counter.n
+}
+
+func (b *MultipartBuilder) reopenable() bool {
+	for _, p := range b.parts {
+		if p.once {
+			return false
+		}
+	}
+	return true
+}
+
+// pipe stream the encoded body, a failure to open or read a part surfaces
+// as a read error of the request body
+func (b *MultipartBuilder) pipe() io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		_, err := b.writeTo(pw)
+		pw.CloseWithError(err)
+	}()
+	return pr
+}
+
+// lazyPipe starts the pipe of b on the first Read, so a request which is never
+// sent doesn't leave the writer goroutine running and the parts open
+type lazyPipe struct {
+	b      *MultipartBuilder
+	mu     sync.Mutex
+	r      io.ReadCloser
+	closed bool
+}
+
+func (l *lazyPipe) Read(p []byte) (int, error) {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return 0, io.ErrClosedPipe
+	}
+	if l.r == nil {
+		l.r = l.b.pipe()
+	}
+	r := l.r
+	l.mu.Unlock()
+	return r.Read(p)
+}
+
+// Close stop the writer when it was started, the transport may call it while
+// a Read is blocked
+func (l *lazyPipe) Close() error {
+	l.mu.Lock()
+	l.closed = true
+	r := l.r
+	l.mu.Unlock()
+	if r != nil {
+		return r.Close()
+	}
+	return nil
+}
+
+type countingWriter struct {
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
+
+type streamedBodyKey struct{}
+
+// isStreamedBody reports whether the body of req is streamed from its source,
+// so reading it again reopens and reads the whole upload
+func isStreamedBody(req *http.Request) bool {
+	streamed, _ := req.Context().Value(streamedBodyKey{}).(bool)
+	return streamed
+}
+
+// WithMultipartStreamOpt stream the multipart body instead of buffering it,
+// for large uploads. Content-Length is set when all part sizes are known,
+// otherwise the body is sent chunked. Retries reopen the parts, which works
+// for fields, files and Stream parts but not for Reader parts
+func WithMultipartStreamOpt(b *MultipartBuilder) RequestOption {
+	return func(req *http.Request) (e error) {
+		if b == nil || req == nil {
+			return fmt.Errorf("WithMultipartStreamOpt error: %v | %v", req, b)
+		}
+		if isStrict(req.Context()) && hasBody(req) {
+			return fmt.Errorf("%w: WithMultipartStreamOpt replaces the body set by an earlier option", ErrStrictMode)
+		}
+
+		b.ensureBoundary()
+		*req = *req.WithContext(context.WithValue(req.Context(), streamedBodyKey{}, true))
+		req.Body = &lazyPipe{b: b}
+		req.ContentLength = b.contentLength()
+		req.GetBody = nil
+		if b.reopenable() {
+			req.GetBody = func() (io.ReadCloser, error) {
+				return &lazyPipe{b: b}, nil
+			}
+		}
+		mw := multipart.NewWriter(ioutil.Discard)
+		if err := mw.SetBoundary(b.boundary); err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		return
+	}
+}
+
+// WithMultipartOpt set the multipart body and its Content-Type with boundary
+func WithMultipartOpt(b *MultipartBuilder) RequestOption {
+	return func(req *http.Request) (e error) {
+		if b == nil || req == nil {
+			return fmt.Errorf("WithMultipartOpt error: %v | %v", req, b)
+		}
+		contentType, body, err := b.Build()
+		if err != nil {
+			return fmt.Errorf("failed to build multipart body: %w", err)
+		}
+		if err := WithBodyOpt(bytes.NewReader(body))(req); err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", contentType)
+		return
+	}
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+func formDisposition(field, filename string) string {
+	d := fmt.Sprintf(`form-data; name="%s"`, quoteEscaper.Replace(field))
+	if filename != "" {
+		d += fmt.Sprintf(`; filename="%s"`, quoteEscaper.Replace(filename))
+	}
+	return d
+}
+
+func fileHeader(field, filename, contentType string) textproto.MIMEHeader {
+	if contentType == "" {
+		contentType = mime.TypeByExtension(filepath.Ext(filename))
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", formDisposition(field, filename))
+	h.Set("Content-Type", contentType)
+	return h
+}
@@ -0,0 +1,95 @@
+package go_http_client
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+const (
+	MediaTypeJSON        = "application/json"
+	MediaTypeXML         = "application/xml"
+	MediaTypePlainText   = "text/plain"
+	MediaTypeOctetStream = "application/octet-stream"
+	MediaTypeAny         = "*/*"
+)
+
+// MediaParser pairs a media type, which may be a wildcard such as "text/*"
+// or "*/*", with the parser for responses of that type
+type MediaParser struct {
+	MediaType string
+	Parser    ResponseParser
+}
+
+// On returns a MediaParser for Negotiate
+func On(mediaType string, parser ResponseParser) MediaParser {
+	return MediaParser{MediaType: mediaType, Parser: parser}
+}
+
+// Negotiate choose the parser by the response Content-Type, trying the media
+// parsers in order. A "+json" or "+xml" structured syntax suffix matches the
+// plain application/json or application/xml entry. Responses without
+// Content-Type only match "*/*"
+func Negotiate(parsers ...MediaParser) ResponseParser {
+	return func(resp *http.Response) (e error) {
+		if resp == nil {
+			return fmt.Errorf("Negotiate function error: %v", resp)
+		}
+
+		mt, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+		for _, p := range parsers {
+			if mediaTypeMatch(p.MediaType, mt) {
+				return p.Parser(resp)
+			}
+		}
+		return fmt.Errorf("unexpected response content type: %q", mt)
+	}
+}
+
+func mediaTypeMatch(pattern, mt string) bool {
+	pattern = strings.ToLower(pattern)
+	switch {
+	case pattern == MediaTypeAny:
+		return true
+	case mt == "":
+		return false
+	case pattern == mt:
+		return true
+	case strings.HasSuffix(pattern, "/*"):
+		return strings.HasPrefix(mt, strings.TrimSuffix(pattern, "*"))
+	}
+
+	// application/problem+json is still JSON
+	if i := strings.LastIndex(mt, "+"); i > 0 {
+		return pattern == "application/"+mt[i+1:]
+	}
+	return false
+}
+
+// MultiParser read the body once and hand a copy of it to each parser in
+// order, e.g. to keep the raw bytes for auditing while decoding the JSON. It
+// stops at the first parser error
+func MultiParser(parsers ...ResponseParser) ResponseParser {
+	return func(resp *http.Response) (e error) {
+		if resp == nil {
+			return fmt.Errorf("MultiParser function error: %v", resp)
+		}
+
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("failed to read resp body: %w", err)
+		}
+		resp.ContentLength = int64(len(body))
+
+		for _, p := range parsers {
+			resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+			if err := p(resp); err != nil {
+				return err
+			}
+		}
+		return
+	}
+}
@@ -0,0 +1,123 @@
+package go_http_client
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type operationKey struct{}
+
+// ContextWithOperation name the operation performed by requests made with ctx
+func ContextWithOperation(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, operationKey{}, name)
+}
+
+// OperationFromContext returns the operation name stored in ctx, if any
+func OperationFromContext(ctx context.Context) string {
+	name, _ := ctx.Value(operationKey{}).(string)
+	return name
+}
+
+// WithOperationOpt name the operation performed by the request, e.g.
+// "reports.generate", used to look up per operation settings
+func WithOperationOpt(name string) RequestOption {
+	return func(req *http.Request) (e error) {
+		*req = *req.WithContext(ContextWithOperation(req.Context(), name))
+		return
+	}
+}
+
+// DefaultTimeout is the default deadline of the requests made through the
+// default http client, see WithTimeout
+const DefaultTimeout = 30 * time.Second
+
+// WithTimeout set the default deadline of a request, including reading the
+// response body. It defaults to DefaultTimeout, or to none when a custom http
+// client is set with WithHttpClient, whose own timeout still applies on top.
+// The streaming and download helpers, DoRequestStream, DownloadFile, the
+// Download functions and ResumeDownload, aren't bounded by it, only by
+// operation and policy timeouts
+func WithTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = d
+		c.timeoutSet = true
+	}
+}
+
+// WithOperationTimeout override the default timeout for an operation. key is
+// either an operation name (see WithOperationOpt) or, when it starts with
+// "/", a request path prefix. Operation names take precedence over paths and
+// the longest matching prefix wins
+func WithOperationTimeout(key string, d time.Duration) Option {
+	return func(c *Client) {
+		if c.operationTimeouts == nil {
+			c.operationTimeouts = make(map[string]time.Duration)
+		}
+		c.operationTimeouts[key] = d
+	}
+}
+
+func (c *Client) timeoutFor(req *http.Request) time.Duration {
+	if d, _, ok := lookupOperation(c.operationTimeouts, OperationFromContext(req.Context()), req.URL.Path); ok {
+		return d
+	}
+	if isTransfer(req.Context()) {
+		return 0
+	}
+	return c.timeout
+}
+
+// lookupOperation find the setting for an operation name or, failing that,
+// for the longest matching path prefix key. The matched key is returned
+func lookupOperation[V any](settings map[string]V, operation, path string) (V, string, bool) {
+	if v, ok := settings[operation]; ok && operation != "" {
+		return v, operation, true
+	}
+
+	var found V
+	matched := ""
+	for key, v := range settings {
+		if strings.HasPrefix(key, "/") && strings.HasPrefix(path, key) && len(key) > len(matched) {
+			found, matched = v, key
+		}
+	}
+	return found, matched, matched != ""
+}
+
+type unboundedKey struct{}
+
+// withoutTimeout exempt the requests made with ctx from the client and policy
+// timeouts, for long lived streams bounded by ctx alone
+func withoutTimeout(ctx context.Context) context.Context {
+	return context.WithValue(ctx, unboundedKey{}, true)
+}
+
+func isUnbounded(ctx context.Context) bool {
+	unbounded, _ := ctx.Value(unboundedKey{}).(bool)
+	return unbounded
+}
+
+type transferKey struct{}
+
+// asTransfer exempt the requests made with ctx from the default timeout, for
+// downloads and streams which take as long as their size requires. Operation
+// and policy timeouts still apply
+func asTransfer(ctx context.Context) context.Context {
+	return context.WithValue(ctx, transferKey{}, true)
+}
+
+func isTransfer(ctx context.Context) bool {
+	transfer, _ := ctx.Value(transferKey{}).(bool)
+	return transfer
+}
+
+// withDeadline bound the request by its timeout
+func (c *Client) withDeadline(req *http.Request, timeout time.Duration) (*http.Request, context.CancelFunc) {
+	if timeout <= 0 {
+		return req, func() {}
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), timeout)
+	return req.WithContext(ctx), cancel
+}
@@ -0,0 +1,10 @@
+module github.com/Traumeel/go-http-client/otel
+
+go 1.21
+
+require (
+	github.com/Traumeel/go-http-client v0.1.0
+	go.opentelemetry.io/otel v1.28.0
+	go.opentelemetry.io/otel/metric v1.28.0
+	go.opentelemetry.io/otel/trace v1.28.0
+)
@@ -0,0 +1,153 @@
+package otel
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	cl "github.com/Traumeel/go-http-client"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+type MetricsOption func(*metricsConfig)
+
+type metricsConfig struct {
+	tagAttributes []string
+}
+
+// WithTagAttributes add the request tags with the given keys as metric
+// attributes, see cl.WithTagOpt. Only use tags with a small set of values
+func WithTagAttributes(keys ...string) MetricsOption {
+	return func(c *metricsConfig) {
+		c.tagAttributes = append(c.tagAttributes, keys...)
+	}
+}
+
+// WithAttributionAttributes add the cost center and caller of the request
+// attribution as the cost_center and caller metric attributes, see
+// cl.ContextWithAttribution
+func WithAttributionAttributes() MetricsOption {
+	return WithTagAttributes(cl.CostCenterTag, cl.CallerTag)
+}
+
+type meters struct {
+	tagAttributes []string
+	duration      metric.Float64Histogram
+	requests      metric.Int64Counter
+	active        metric.Int64UpDownCounter
+	// started holds the start of the requests in flight, for the duration
+	// of those which fail without a response
+	started sync.Map
+}
+
+// WithOTelMetrics record the HTTP client metrics of the semantic
+// conventions with the meter of mp: the http.client.request.duration
+// histogram and the http.client.active_requests up down counter, plus an
+// http.client.requests counter. Requests which got no response are recorded
+// with their error.type and no status
+func WithOTelMetrics(mp metric.MeterProvider, options ...MetricsOption) cl.Option {
+	cfg := metricsConfig{}
+	for _, opt := range options {
+		opt(&cfg)
+	}
+
+	m, err := newMeters(mp, cfg.tagAttributes)
+	if err != nil {
+		// options can't fail, an invalid instrument is a programming error
+		panic(fmt.Errorf("WithOTelMetrics error: %w", err))
+	}
+
+	return func(c *cl.Client) {
+		cl.OnRequest(m.onRequest)(c)
+		cl.OnResponse(m.onResponse)(c)
+		cl.OnError(m.onError)(c)
+	}
+}
+
+func newMeters(mp metric.MeterProvider, tagAttributes []string) (*meters, error) {
+	meter := mp.Meter(instrumentationName)
+	m := &meters{tagAttributes: tagAttributes}
+
+	var err error
+	if m.duration, err = meter.Float64Histogram("http.client.request.duration",
+		metric.WithUnit("s"),
+		metric.WithDescription("Duration of HTTP client requests."),
+		metric.WithExplicitBucketBoundaries(0.005, 0.01, 0.025, 0.05, 0.075, 0.1, 0.25, 0.5, 0.75, 1, 2.5, 5, 7.5, 10),
+	); err != nil {
+		return nil, err
+	}
+	if m.requests, err = meter.Int64Counter("http.client.requests",
+		metric.WithUnit("{request}"),
+		metric.WithDescription("Number of HTTP client requests."),
+	); err != nil {
+		return nil, err
+	}
+	if m.active, err = meter.Int64UpDownCounter("http.client.active_requests",
+		metric.WithUnit("{request}"),
+		metric.WithDescription("Number of outbound HTTP requests that are currently active on the client."),
+	); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (m *meters) serverAttributes(req *http.Request) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		attribute.String("http.request.method", req.Method),
+		attribute.String("server.address", req.URL.Hostname()),
+	}
+	if port, err := strconv.Atoi(req.URL.Port()); err == nil {
+		attrs = append(attrs, attribute.Int("server.port", port))
+	}
+	if len(m.tagAttributes) > 0 {
+		tags := cl.TagsFromContext(req.Context())
+		for _, k := range m.tagAttributes {
+			if v, ok := tags[k]; ok {
+				attrs = append(attrs, attribute.String(k, v))
+			}
+		}
+	}
+	return attrs
+}
+
+func (m *meters) onRequest(req *http.Request) {
+	m.started.Store(req, time.Now())
+	m.active.Add(req.Context(), 1, metric.WithAttributes(m.serverAttributes(req)...))
+}
+
+func (m *meters) onResponse(req *http.Request, resp *cl.Response) {
+	m.started.Delete(req)
+	attrs := m.serverAttributes(req)
+	m.active.Add(req.Context(), -1, metric.WithAttributes(attrs...))
+
+	attrs = append(attrs, attribute.Int("http.response.status_code", resp.StatusCode))
+	if resp.StatusCode >= 400 {
+		attrs = append(attrs, attribute.String("error.type", strconv.Itoa(resp.StatusCode)))
+	}
+	m.record(req, resp.Duration, attrs)
+}
+
+func (m *meters) onError(req *http.Request, resp *cl.Response, err error) {
+	// a received response was recorded already
+	if resp != nil {
+		return
+	}
+	attrs := m.serverAttributes(req)
+	m.active.Add(req.Context(), -1, metric.WithAttributes(attrs...))
+
+	attrs = append(attrs, attribute.String("error.type", cl.ClassifyError(err).String()))
+	var d time.Duration
+	if start, ok := m.started.LoadAndDelete(req); ok {
+		d = time.Since(start.(time.Time))
+	}
+	m.record(req, d, attrs)
+}
+
+func (m *meters) record(req *http.Request, d time.Duration, attrs []attribute.KeyValue) {
+	set := metric.WithAttributes(attrs...)
+	m.duration.Record(req.Context(), d.Seconds(), set)
+	m.requests.Add(req.Context(), 1, set)
+}
@@ -0,0 +1,30 @@
+package otel
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	cl "github.com/Traumeel/go-http-client"
+)
+
+func TestAttributionAttributes(t *testing.T) {
+	cfg := metricsConfig{}
+	WithAttributionAttributes()(&cfg)
+	m := &meters{tagAttributes: cfg.tagAttributes}
+
+	ctx := cl.ContextWithAttribution(context.Background(), cl.Attribution{CostCenter: "cc-42", Caller: "billing"})
+	c := cl.NewClient("http://api.example.com")
+	req, err := c.PrepareRequest(ctx, http.MethodGet, "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	attrs := map[string]string{}
+	for _, kv := range m.serverAttributes(req) {
+		attrs[string(kv.Key)] = kv.Value.Emit()
+	}
+	if attrs[cl.CostCenterTag] != "cc-42" || attrs[cl.CallerTag] != "billing" {
+		t.Errorf("attributes = %v", attrs)
+	}
+}
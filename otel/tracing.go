@@ -0,0 +1,86 @@
+// Package otel adds OpenTelemetry tracing and metrics to the client. It
+// lives in its own module so the OpenTelemetry libraries are only pulled in
+// by the users who need them
+package otel
+
+import (
+	"net/http"
+
+	cl "github.com/Traumeel/go-http-client"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName identifies the tracer and the meter of the client
+const instrumentationName = "github.com/Traumeel/go-http-client/otel"
+
+type TracingOption func(*tracingConfig)
+
+type tracingConfig struct {
+	propagator propagation.TextMapPropagator
+}
+
+// WithPropagator inject the trace context with p instead of the W3C
+// traceparent and tracestate headers
+func WithPropagator(p propagation.TextMapPropagator) TracingOption {
+	return func(c *tracingConfig) {
+		c.propagator = p
+	}
+}
+
+// WithOTelTracing create a client span per request, a child of the span in
+// the request context, and inject it into the request headers. The span
+// starts once the request options are applied, covers the retries and ends when
+// the response headers were received or the request failed, with the
+// method, URL, status, request tags and attribution as attributes
+func WithOTelTracing(tp trace.TracerProvider, options ...TracingOption) cl.Option {
+	cfg := tracingConfig{propagator: propagation.TraceContext{}}
+	for _, opt := range options {
+		opt(&cfg)
+	}
+	tracer := tp.Tracer(instrumentationName)
+
+	return cl.WithSpanStarter(func(req *http.Request) func(*cl.Response, error) {
+		name := req.Method
+		if op := cl.OperationFromContext(req.Context()); op != "" {
+			name += " " + op
+		}
+		ctx, span := tracer.Start(req.Context(), name,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				attribute.String("http.request.method", req.Method),
+				attribute.String("url.full", cl.RedactedURL(req)),
+				attribute.String("server.address", req.URL.Hostname()),
+			),
+		)
+		for k, v := range cl.TagsFromContext(req.Context()) {
+			span.SetAttributes(attribute.String(k, v))
+		}
+		cfg.propagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+		*req = *req.WithContext(ctx)
+
+		return func(resp *cl.Response, err error) {
+			endSpan(span, resp, err)
+		}
+	})
+}
+
+func endSpan(span trace.Span, resp *cl.Response, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetAttributes(attribute.String("error.type", cl.ClassifyError(err).String()))
+		span.SetStatus(codes.Error, err.Error())
+		span.End()
+		return
+	}
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	if resp.Attempts > 1 {
+		span.SetAttributes(attribute.Int("http.request.resend_count", resp.Attempts-1))
+	}
+	if resp.StatusCode >= 400 {
+		span.SetStatus(codes.Error, resp.Status)
+	}
+	span.End()
+}
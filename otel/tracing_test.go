@@ -0,0 +1,144 @@
+package otel
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	cl "github.com/Traumeel/go-http-client"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// recordingSpan keeps the attributes set on it and whether it ended
+type recordingSpan struct {
+	noop.Span
+	sc    trace.SpanContext
+	mu    sync.Mutex
+	attrs map[attribute.Key]string
+	ended int
+}
+
+func (s *recordingSpan) SpanContext() trace.SpanContext { return s.sc }
+func (s *recordingSpan) IsRecording() bool              { return true }
+
+func (s *recordingSpan) SetAttributes(kv ...attribute.KeyValue) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, a := range kv {
+		s.attrs[a.Key] = a.Value.Emit()
+	}
+}
+
+func (s *recordingSpan) End(...trace.SpanEndOption) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ended++
+}
+
+type recordingTracer struct {
+	noop.Tracer
+	mu    sync.Mutex
+	spans []*recordingSpan
+}
+
+func (t *recordingTracer) Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	span := &recordingSpan{
+		sc: trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID:    trace.TraceID{1},
+			SpanID:     trace.SpanID{2},
+			TraceFlags: trace.FlagsSampled,
+		}),
+		attrs: map[attribute.Key]string{},
+	}
+	cfg := trace.NewSpanStartConfig(opts...)
+	span.SetAttributes(cfg.Attributes()...)
+	t.mu.Lock()
+	t.spans = append(t.spans, span)
+	t.mu.Unlock()
+	return trace.ContextWithSpan(ctx, span), span
+}
+
+func (t *recordingTracer) started() []*recordingSpan {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]*recordingSpan(nil), t.spans...)
+}
+
+type recordingProvider struct {
+	noop.TracerProvider
+	tracer *recordingTracer
+}
+
+func (p recordingProvider) Tracer(string, ...trace.TracerOption) trace.Tracer { return p.tracer }
+
+func TestTracingAfterRequestOptions(t *testing.T) {
+	var traceparent string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("Traceparent")
+	}))
+	defer srv.Close()
+
+	tracer := &recordingTracer{}
+	c := cl.NewClient(srv.URL, WithOTelTracing(recordingProvider{tracer: tracer}))
+	ctx := cl.ContextWithAttribution(context.Background(), cl.Attribution{CostCenter: "cc-42"})
+
+	err := c.DoRequestNoBody(ctx, http.MethodGet, "/users/42",
+		cl.WithTagOpt("tenant", "acme"),
+		cl.WithHeadersReplaceOpt(http.Header{"Accept": {"*/*"}}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spans := tracer.started()
+	if len(spans) != 1 || spans[0].ended != 1 {
+		t.Fatalf("spans = %+v, want one ended span", spans)
+	}
+	if spans[0].attrs["tenant"] != "acme" || spans[0].attrs[cl.CostCenterTag] != "cc-42" {
+		t.Errorf("attributes = %v", spans[0].attrs)
+	}
+	if traceparent == "" {
+		t.Error("traceparent not sent")
+	}
+}
+
+func TestTracingEndsOnEveryPath(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	tracer := &recordingTracer{}
+	c := cl.NewClient(srv.URL, WithOTelTracing(recordingProvider{tracer: tracer}))
+
+	failing := func(*http.Request) error { return errors.New("boom") }
+	if err := c.DoRequestNoBody(context.Background(), http.MethodGet, "/", failing); err == nil {
+		t.Fatal("failing option didn't fail the request")
+	}
+	if err := c.DownloadFile(context.Background(), http.MethodGet, "/", discard{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.DoRequestNoBody(context.Background(), http.MethodGet, "/"); !errors.Is(err, cl.ErrClientClosed) {
+		t.Fatalf("err = %v, want ErrClientClosed", err)
+	}
+
+	spans := tracer.started()
+	if len(spans) != 1 {
+		t.Fatalf("started %v spans, want only the download", len(spans))
+	}
+	for _, span := range spans {
+		if span.ended != 1 {
+			t.Errorf("span ended %v times", span.ended)
+		}
+	}
+}
+
+type discard struct{}
+
+func (discard) Write(p []byte) (int, error) { return len(p), nil }
@@ -0,0 +1,369 @@
+package go_http_client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrCircuitOpen is returned without sending the request while the circuit
+// breaker of the operation is open
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// Duration is a time.Duration written as a string such as "1.5s" in configs
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string such as \"1s\": %w", err)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// BackoffPolicy sets the delay between retries: Initial * Multiplier^n capped
+// at Max, randomized between 0 and the delay when Jitter is set
+type BackoffPolicy struct {
+	Initial    Duration `json:"initial,omitempty"`
+	Max        Duration `json:"max,omitempty"`
+	Multiplier float64  `json:"multiplier,omitempty"`
+	Jitter     bool     `json:"jitter,omitempty"`
+}
+
+// BreakerPolicy opens the circuit after FailureThreshold consecutive
+// failures (transport errors and 5xx responses) for OpenFor, then lets a
+// single trial request through before closing it again
+type BreakerPolicy struct {
+	FailureThreshold int      `json:"failure_threshold"`
+	OpenFor          Duration `json:"open_for"`
+}
+
+// Policy is the resilience configuration of an operation
+type Policy struct {
+	// Timeout overrides the client and operation timeout, covering all attempts
+	Timeout Duration `json:"timeout,omitempty"`
+	// MaxRetries is the number of retries after the first attempt
+	MaxRetries int           `json:"max_retries,omitempty"`
+	Backoff    BackoffPolicy `json:"backoff,omitempty"`
+	// RetryStatuses are the retried response codes, 429, 502, 503 and 504
+	// when empty. Transport errors are retried unless IsRetryable classifies
+	// them as permanent
+	RetryStatuses []int `json:"retry_statuses,omitempty"`
+	// RetryNonIdempotent allow retrying POST and PATCH requests
+	RetryNonIdempotent bool           `json:"retry_non_idempotent,omitempty"`
+	Breaker            *BreakerPolicy `json:"breaker,omitempty"`
+}
+
+// PolicyConfig maps operations to policies, the keys are operation names or
+// path prefixes as for WithOperationTimeout. Default applies to the rest
+type PolicyConfig struct {
+	Default    Policy            `json:"default"`
+	Operations map[string]Policy `json:"operations,omitempty"`
+}
+
+// PolicyStore holds the current policy config and can be updated at any time,
+// e.g. from a config file watched with WatchFile
+type PolicyStore struct {
+	cfg atomic.Value
+
+	mu      sync.Mutex
+	modTime time.Time
+}
+
+func NewPolicyStore(cfg PolicyConfig) *PolicyStore {
+	s := &PolicyStore{}
+	s.Store(cfg)
+	return s
+}
+
+// Store replace the policy config, requests started afterwards use it
+func (s *PolicyStore) Store(cfg PolicyConfig) {
+	s.cfg.Store(&cfg)
+}
+
+// Load returns the current policy config
+func (s *PolicyStore) Load() PolicyConfig {
+	return *s.cfg.Load().(*PolicyConfig)
+}
+
+// LoadFile replace the policy config with the JSON config in path
+func (s *PolicyStore) LoadFile(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	cfg := PolicyConfig{}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("failed to parse policy config %v: %w", path, err)
+	}
+	s.Store(cfg)
+	return nil
+}
+
+// WatchFile reload path whenever its modification time changes, checking
+// every interval until ctx is done. Failed reloads keep the previous config
+// and are reported to onError, which may be nil
+func (s *PolicyStore) WatchFile(ctx context.Context, path string, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.reloadIfChanged(path); err != nil && onError != nil {
+			onError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *PolicyStore) reloadIfChanged(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if info.ModTime().Equal(s.modTime) {
+		return nil
+	}
+	if err := s.LoadFile(path); err != nil {
+		return err
+	}
+	s.modTime = info.ModTime()
+	return nil
+}
+
+// WithPolicyConfig apply per operation retry, backoff, timeout and circuit
+// breaker policies from the store
+func WithPolicyConfig(store *PolicyStore) Option {
+	return func(c *Client) {
+		c.policies = store
+	}
+}
+
+// policyFor returns the policy of the request and the key it was found under,
+// the key identifies the circuit breaker
+func (c *Client) policyFor(req *http.Request) (Policy, string) {
+	if c.policies == nil {
+		return Policy{}, ""
+	}
+
+	cfg := c.policies.Load()
+	if p, key, ok := lookupOperation(cfg.Operations, OperationFromContext(req.Context()), req.URL.Path); ok {
+		return p, key
+	}
+	return cfg.Default, ""
+}
+
+func (p Policy) retryable(req *http.Request, resp *http.Response, err error) bool {
+	if req.Context().Err() != nil {
+		return false
+	}
+	if !p.RetryNonIdempotent && (req.Method == http.MethodPost || req.Method == http.MethodPatch) {
+		return false
+	}
+	if hasBody(req) && req.GetBody == nil {
+		return false
+	}
+	if err != nil {
+		// errors of custom transports can't be classified, keep retrying them
+		return IsRetryable(err) || ClassifyError(err) == ErrorClassUnknown
+	}
+
+	statuses := p.RetryStatuses
+	if len(statuses) == 0 {
+		statuses = []int{http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout}
+	}
+	for _, code := range statuses {
+		if resp.StatusCode == code {
+			return true
+		}
+	}
+	return false
+}
+
+// delay returns the wait before retry n, honouring Retry-After when the
+// server sent one
+func (b BackoffPolicy) delay(n int, resp *http.Response) time.Duration {
+	initial, max, multiplier := time.Duration(b.Initial), time.Duration(b.Max), b.Multiplier
+	if initial <= 0 {
+		initial = 100 * time.Millisecond
+	}
+	if max <= 0 {
+		max = 10 * time.Second
+	}
+	if multiplier < 1 {
+		multiplier = 2
+	}
+
+	if resp != nil {
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds >= 0 {
+			if d := time.Duration(seconds) * time.Second; d < max {
+				return d
+			}
+			return max
+		}
+	}
+
+	d := time.Duration(float64(initial) * math.Pow(multiplier, float64(n-1)))
+	if d > max || d <= 0 {
+		d = max
+	}
+	if b.Jitter {
+		d = time.Duration(rand.Int63n(int64(d) + 1))
+	}
+	return d
+}
+
+// retryRequest returns a copy of req with a fresh body for another attempt
+func retryRequest(req *http.Request) (*http.Request, error) {
+	r := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("failed to rewind request body: %w", err)
+		}
+		r.Body = body
+		trackUpload(r)
+	}
+	return r, nil
+}
+
+// send the request applying the retry and breaker policy
+func (c *Client) send(req *http.Request, p Policy, key string, entry *journalEntry) (*http.Response, *Response, error) {
+	b := c.breakers.get(key)
+	if p.Breaker != nil && !b.allow(*p.Breaker, c.clock.Now()) {
+		return nil, nil, fmt.Errorf("%w: %v", ErrCircuitOpen, key)
+	}
+
+	start := time.Now()
+	for attempt := 1; ; attempt++ {
+		// attempts work on copies so headers added on the way, such as
+		// cookies, don't pile up on retries
+		areq := req.Clone(req.Context())
+		if attempt > 1 {
+			r, err := retryRequest(req)
+			if err != nil {
+				return nil, nil, err
+			}
+			areq = r
+			entry.setAttempt(attempt)
+		}
+
+		if c.debugFor(areq) {
+			logRequest(areq, c.log, c.redactor)
+		}
+
+		areq, trace := withPhaseTrace(areq)
+		resp, err := c.do(areq)
+		if p.Breaker != nil {
+			b.record(*p.Breaker, err != nil || resp.StatusCode >= 500, c.clock.Now())
+		}
+
+		if attempt > p.MaxRetries || !p.retryable(areq, resp, err) {
+			if err != nil {
+				return nil, nil, err
+			}
+			meta := newResponse(areq, resp, time.Since(start))
+			meta.Attempts = attempt
+			meta.Timings = trace.timings()
+			if c.debugFor(areq) {
+				logTimings(areq, meta.Timings, c.log)
+			}
+			return resp, meta, nil
+		}
+
+		wait := p.Backoff.delay(attempt, resp)
+		if resp != nil {
+			c.closeBody(resp.Body)
+		}
+
+		select {
+		case <-req.Context().Done():
+			return nil, nil, req.Context().Err()
+		case <-c.clock.After(wait):
+		}
+	}
+}
+
+type breakers struct {
+	mu    sync.Mutex
+	byKey map[string]*breaker
+}
+
+func newBreakers() *breakers {
+	return &breakers{byKey: make(map[string]*breaker)}
+}
+
+func (bs *breakers) get(key string) *breaker {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	b, ok := bs.byKey[key]
+	if !ok {
+		b = &breaker{}
+		bs.byKey[key] = b
+	}
+	return b
+}
+
+type breaker struct {
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	trial     bool
+}
+
+func (b *breaker) allow(p BreakerPolicy, now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if p.FailureThreshold <= 0 || b.failures < p.FailureThreshold {
+		return true
+	}
+	if now.Before(b.openUntil) || b.trial {
+		return false
+	}
+	// half open, let a single request find out whether the upstream is back
+	b.trial = true
+	return true
+}
+
+func (b *breaker) record(p BreakerPolicy, failed bool, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trial = false
+	if !failed {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if p.FailureThreshold > 0 && b.failures >= p.FailureThreshold {
+		b.openUntil = now.Add(time.Duration(p.OpenFor))
+	}
+}
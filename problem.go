@@ -0,0 +1,74 @@
+package go_http_client
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// MediaTypeProblemJSON is the media type of RFC 7807 problem details
+const MediaTypeProblemJSON = "application/problem+json"
+
+// ProblemDetails is an RFC 7807 problem details error body. ResponseValidator
+// returns it for application/problem+json error responses, wrapping the
+// StatusCodeError it would have returned otherwise
+type ProblemDetails struct {
+	Type     string
+	Title    string
+	Status   int
+	Detail   string
+	Instance string
+	// Extensions holds the members beyond the standard ones
+	Extensions map[string]interface{}
+
+	statusErr StatusCodeError
+}
+
+func (t ProblemDetails) Error() string {
+	msg := t.Title
+	if t.Detail != "" {
+		msg += ": " + t.Detail
+	}
+	return fmt.Sprintf("error: %v | %v | %v", t.statusErr.Code, t.statusErr.Status, msg)
+}
+
+// Unwrap returns the StatusCodeError of the response
+func (t ProblemDetails) Unwrap() error {
+	return t.statusErr
+}
+
+func (t ProblemDetails) HTTPStatusCode() int {
+	return t.statusErr.Code
+}
+
+// newProblemDetails decode body, returning false when it isn't a problem
+// details object
+func newProblemDetails(statusErr StatusCodeError, body []byte) (ProblemDetails, bool) {
+	var members map[string]interface{}
+	if err := json.Unmarshal(body, &members); err != nil {
+		return ProblemDetails{}, false
+	}
+
+	p := ProblemDetails{Type: "about:blank", statusErr: statusErr}
+	for k, v := range members {
+		switch k {
+		case "type":
+			p.Type, _ = v.(string)
+		case "title":
+			p.Title, _ = v.(string)
+		case "status":
+			if status, ok := v.(float64); ok {
+				p.Status = int(status)
+			}
+		case "detail":
+			p.Detail, _ = v.(string)
+		case "instance":
+			p.Instance, _ = v.(string)
+		default:
+			if p.Extensions == nil {
+				p.Extensions = make(map[string]interface{})
+			}
+			p.Extensions[k] = v
+		}
+	}
+	return p, true
+}
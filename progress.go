@@ -0,0 +1,71 @@
+package go_http_client
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+// ProgressFunc receives the bytes transferred so far and the total size,
+// -1 when the size is unknown
+type ProgressFunc func(transferred, total int64)
+
+type progressKey struct{}
+type uploadProgressKey struct{}
+
+// WithProgressOpt report the progress of reading the response body
+func WithProgressOpt(fn ProgressFunc) RequestOption {
+	return func(req *http.Request) (e error) {
+		*req = *req.WithContext(context.WithValue(req.Context(), progressKey{}, fn))
+		return
+	}
+}
+
+// WithUploadProgressOpt report the progress of sending the request body,
+// a retried request starts over from 0
+func WithUploadProgressOpt(fn ProgressFunc) RequestOption {
+	return func(req *http.Request) (e error) {
+		*req = *req.WithContext(context.WithValue(req.Context(), uploadProgressKey{}, fn))
+		return
+	}
+}
+
+type progressReader struct {
+	io.ReadCloser
+	fn          ProgressFunc
+	transferred int64
+	total       int64
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 {
+		r.transferred += int64(n)
+		r.fn(r.transferred, r.total)
+	}
+	return n, err
+}
+
+// trackUpload report the progress of sending the body of req. Only the body
+// sent is tracked, not the copies read through GetBody by the recorders, so
+// retryRequest tracks the rewound body of each attempt
+func trackUpload(req *http.Request) {
+	fn, _ := req.Context().Value(uploadProgressKey{}).(ProgressFunc)
+	if fn == nil || !hasBody(req) {
+		return
+	}
+
+	total := req.ContentLength
+	if total == 0 {
+		total = -1
+	}
+	req.Body = &progressReader{ReadCloser: req.Body, fn: fn, total: total}
+}
+
+func trackDownload(req *http.Request, resp *http.Response) {
+	fn, _ := req.Context().Value(progressKey{}).(ProgressFunc)
+	if fn == nil {
+		return
+	}
+	resp.Body = &progressReader{ReadCloser: resp.Body, fn: fn, total: resp.ContentLength}
+}
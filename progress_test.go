@@ -0,0 +1,46 @@
+package go_http_client
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestUploadProgressIgnoresRecorders(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(ioutil.Discard, r.Body)
+	}))
+	defer srv.Close()
+
+	a, err := NewArchiver(ArchiveConfig{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	c := NewClient(srv.URL, WithHARRecorder(NewHARRecorder(0)), WithArchive("", a), WithRequestHash("X-Request-Hash"))
+
+	body := strings.Repeat("x", 1000)
+	var reports []int64
+	err = c.DoRequestNoBody(context.Background(), http.MethodPost, "/upload",
+		WithBodyOpt(strings.NewReader(body)),
+		WithUploadProgressOpt(func(transferred, total int64) {
+			reports = append(reports, transferred)
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(reports) == 0 || reports[len(reports)-1] != int64(len(body)) {
+		t.Fatalf("reports = %v, want to end at %v", reports, len(body))
+	}
+	for i := 1; i < len(reports); i++ {
+		if reports[i] <= reports[i-1] {
+			t.Fatalf("progress went backwards: %v", reports)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+// Package prometheus exports the request metrics of the client to
+// Prometheus. It lives in its own module so the Prometheus client library is
+// only pulled in by the users who need it
+package prometheus
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	cl "github.com/Traumeel/go-http-client"
+	promclient "github.com/prometheus/client_golang/prometheus"
+)
+
+type Option func(*config)
+
+type config struct {
+	tagLabels []string
+}
+
+// WithTagLabels add the request tags with the given keys as labels, see
+// cl.WithTagOpt. Requests without the tag have an empty label value, only
+// use tags with a small set of values
+func WithTagLabels(keys ...string) Option {
+	return func(c *config) {
+		c.tagLabels = append(c.tagLabels, keys...)
+	}
+}
+
+// WithAttributionLabels add the cost center and caller of the request
+// attribution as the cost_center and caller labels, see
+// cl.ContextWithAttribution
+func WithAttributionLabels() Option {
+	return WithTagLabels(cl.CostCenterTag, cl.CallerTag)
+}
+
+type metrics struct {
+	tagLabels []string
+	requests  *promclient.CounterVec
+	errors    *promclient.CounterVec
+	inFlight  *promclient.GaugeVec
+	duration  *promclient.HistogramVec
+}
+
+// WithPrometheus register the client metrics with registerer under namespace:
+//
+//	http_client_requests_total            responses by method, host and status class
+//	http_client_errors_total              failed requests by method, host and error class
+//	http_client_in_flight_requests        requests waiting for their response
+//	http_client_request_duration_seconds  time until the response headers, including retries
+//
+// Clients sharing a registerer share the metrics, registering them twice
+// reuses the registered collectors, which requires the same tag labels
+func WithPrometheus(registerer promclient.Registerer, namespace string, options ...Option) cl.Option {
+	cfg := config{}
+	for _, opt := range options {
+		opt(&cfg)
+	}
+
+	m, err := newMetrics(registerer, namespace, cfg.tagLabels)
+	if err != nil {
+		// options can't fail, an invalid registration is a programming error
+		panic(fmt.Errorf("WithPrometheus error: %w", err))
+	}
+
+	return func(c *cl.Client) {
+		cl.OnRequest(m.onRequest)(c)
+		cl.OnResponse(m.onResponse)(c)
+		cl.OnError(m.onError)(c)
+	}
+}
+
+func newMetrics(registerer promclient.Registerer, namespace string, tagLabels []string) (*metrics, error) {
+	labels := append([]string{"method", "host"}, tagLabels...)
+	labels = labels[:len(labels):len(labels)]
+	m := &metrics{
+		tagLabels: tagLabels,
+		requests: promclient.NewCounterVec(promclient.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_client_requests_total",
+			Help:      "HTTP responses received, by status class.",
+		}, append(labels, "status")),
+		errors: promclient.NewCounterVec(promclient.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_client_errors_total",
+			Help:      "HTTP requests which failed, by error class.",
+		}, append(labels, "class")),
+		inFlight: promclient.NewGaugeVec(promclient.GaugeOpts{
+			Namespace: namespace,
+			Name:      "http_client_in_flight_requests",
+			Help:      "HTTP requests waiting for their response.",
+		}, labels),
+		duration: promclient.NewHistogramVec(promclient.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_client_request_duration_seconds",
+			Help:      "Time until the HTTP response headers were received, including retries.",
+			Buckets:   promclient.DefBuckets,
+		}, append(labels, "status")),
+	}
+
+	var err error
+	if m.requests, err = register(registerer, m.requests); err != nil {
+		return nil, err
+	}
+	if m.errors, err = register(registerer, m.errors); err != nil {
+		return nil, err
+	}
+	if m.inFlight, err = register(registerer, m.inFlight); err != nil {
+		return nil, err
+	}
+	if m.duration, err = register(registerer, m.duration); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// register c, or return the collector registered before under the same name
+func register[C promclient.Collector](registerer promclient.Registerer, c C) (C, error) {
+	if err := registerer.Register(c); err != nil {
+		if already, ok := err.(promclient.AlreadyRegisteredError); ok {
+			if existing, ok := already.ExistingCollector.(C); ok {
+				return existing, nil
+			}
+		}
+		return c, err
+	}
+	return c, nil
+}
+
+func statusClass(code int) string {
+	return strconv.Itoa(code/100) + "xx"
+}
+
+// labelValues returns the method, host and tag label values of req followed
+// by extra
+func (m *metrics) labelValues(req *http.Request, extra ...string) []string {
+	values := []string{req.Method, req.URL.Host}
+	if len(m.tagLabels) > 0 {
+		tags := cl.TagsFromContext(req.Context())
+		for _, k := range m.tagLabels {
+			values = append(values, tags[k])
+		}
+	}
+	return append(values, extra...)
+}
+
+func (m *metrics) onRequest(req *http.Request) {
+	m.inFlight.WithLabelValues(m.labelValues(req)...).Inc()
+}
+
+func (m *metrics) onResponse(req *http.Request, resp *cl.Response) {
+	status := statusClass(resp.StatusCode)
+	m.inFlight.WithLabelValues(m.labelValues(req)...).Dec()
+	m.requests.WithLabelValues(m.labelValues(req, status)...).Inc()
+	m.duration.WithLabelValues(m.labelValues(req, status)...).Observe(resp.Duration.Seconds())
+}
+
+func (m *metrics) onError(req *http.Request, resp *cl.Response, err error) {
+	if resp == nil {
+		// the response hook didn't run, the request is no longer in flight
+		m.inFlight.WithLabelValues(m.labelValues(req)...).Dec()
+	}
+	m.errors.WithLabelValues(m.labelValues(req, cl.ClassifyError(err).String())...).Inc()
+}
@@ -0,0 +1,34 @@
+package prometheus
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	cl "github.com/Traumeel/go-http-client"
+	promclient "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestAttributionLabels(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	reg := promclient.NewRegistry()
+	c := cl.NewClient(srv.URL, WithPrometheus(reg, "test", WithAttributionLabels()))
+	ctx := cl.ContextWithAttribution(context.Background(), cl.Attribution{CostCenter: "cc-42", Caller: "billing"})
+	if err := c.Get(ctx, "/"); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := newMetrics(reg, "test", []string{cl.CostCenterTag, cl.CallerTag})
+	if err != nil {
+		t.Fatal(err)
+	}
+	host := srv.Listener.Addr().String()
+	got := testutil.ToFloat64(m.requests.WithLabelValues("GET", host, "cc-42", "billing", "2xx"))
+	if got != 1 {
+		t.Errorf("requests with the attribution labels = %v, want 1", got)
+	}
+}
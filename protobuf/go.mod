@@ -0,0 +1,8 @@
+module github.com/Traumeel/go-http-client/protobuf
+
+go 1.23
+
+require (
+	github.com/Traumeel/go-http-client v0.1.0
+	google.golang.org/protobuf v1.36.12
+)
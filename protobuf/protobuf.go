@@ -0,0 +1,48 @@
+// Package protobuf adds protobuf request bodies and response parsers to the
+// client, for gRPC-gateway or Twirp style endpoints. It lives in its own
+// module so the protobuf runtime is only pulled in by the users who need it
+package protobuf
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	cl "github.com/Traumeel/go-http-client"
+	"google.golang.org/protobuf/proto"
+)
+
+// ContentType is the media type of binary protobuf bodies, some servers use
+// application/protobuf or application/octet-stream
+const ContentType = "application/x-protobuf"
+
+func decode(r io.Reader, v interface{}) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("protobuf.Parser expects a proto.Message, got %T", v)
+	}
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("failed to read resp body: %w", err)
+	}
+	return proto.Unmarshal(data, m)
+}
+
+func marshal(v interface{}) ([]byte, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("protobuf.WithBodyOpt expects a proto.Message, got %T", v)
+	}
+	return proto.Marshal(m)
+}
+
+// Parser decode the binary protobuf response into m
+func Parser(m proto.Message) cl.ResponseParser {
+	return cl.DecodingParser(m, decode)
+}
+
+// WithBodyOpt marshal m as the binary protobuf request body and set the
+// Content-Type
+func WithBodyOpt(m proto.Message) cl.RequestOption {
+	return cl.WithEncodedBodyOpt(m, ContentType, marshal)
+}
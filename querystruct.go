@@ -0,0 +1,244 @@
+package go_http_client
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// QueryEncoder is implemented by types with a custom query encoding
+type QueryEncoder interface {
+	EncodeValues(key string, v *url.Values) error
+}
+
+var (
+	timeType         = reflect.TypeOf(time.Time{})
+	queryEncoderType = reflect.TypeOf((*QueryEncoder)(nil)).Elem()
+)
+
+// WithQueryStructOpt add the fields of a struct to the request query, merging
+// them like WithQueryOpt, see EncodeQuery for the supported tags
+func WithQueryStructOpt(v interface{}) RequestOption {
+	return func(req *http.Request) (e error) {
+		if v == nil || req == nil {
+			return fmt.Errorf("WithQueryStructOpt error: %v | %v", req, v)
+		}
+		query, err := EncodeQuery(v)
+		if err != nil {
+			return err
+		}
+		return WithQueryOpt(query)(req)
+	}
+}
+
+// EncodeQuery encode a struct into query values using `url` field tags:
+//
+//	Status []string  `url:"status,omitempty"`  // status=a&status=b
+//	IDs    []int     `url:"ids,comma"`         // ids=1,2,3 (also space, semicolon)
+//	Tags   []string  `url:"tag,brackets"`      // tag[]=a&tag[]=b
+//	Since  time.Time `url:"since,unix"`        // also unixmilli, unixnano
+//	Until  time.Time `url:"until" layout:"2006-01-02"`
+//	Active bool      `url:"active,int"`        // active=1
+//	Secret string    `url:"-"`
+//
+// Untagged fields use the field name, embedded structs are flattened and
+// nested structs are encoded as parent[child]. Times default to RFC 3339
+func EncodeQuery(v interface{}) (url.Values, error) {
+	values := make(url.Values)
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return values, nil
+		}
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("EncodeQuery expects a struct, got %T", v)
+	}
+
+	return values, encodeStruct(values, rv, "")
+}
+
+func encodeStruct(values url.Values, rv reflect.Value, scope string) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+
+		tag := field.Tag.Get("url")
+		if tag == "-" {
+			continue
+		}
+		name, opts := parseQueryTag(tag)
+
+		fv := rv.Field(i)
+		if field.Anonymous && name == "" {
+			for fv.Kind() == reflect.Ptr {
+				if fv.IsNil() {
+					break
+				}
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct {
+				if err := encodeStruct(values, fv, scope); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if !fv.CanInterface() {
+			// an unexported embedded field which isn't a struct, skipped
+			// as encoding/json does
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		if scope != "" {
+			name = scope + "[" + name + "]"
+		}
+
+		if opts.has("omitempty") && isEmptyValue(fv) {
+			continue
+		}
+
+		if err := encodeField(values, name, fv, opts, field.Tag.Get("layout")); err != nil {
+			return fmt.Errorf("failed to encode query field %v: %w", field.Name, err)
+		}
+	}
+	return nil
+}
+
+func encodeField(values url.Values, name string, fv reflect.Value, opts queryTagOptions, layout string) error {
+	if fv.Type().Implements(queryEncoderType) {
+		if fv.Kind() == reflect.Ptr && fv.IsNil() {
+			return nil
+		}
+		return fv.Interface().(QueryEncoder).EncodeValues(name, &values)
+	}
+
+	for fv.Kind() == reflect.Ptr {
+		if fv.IsNil() {
+			values.Add(name, "")
+			return nil
+		}
+		fv = fv.Elem()
+	}
+
+	switch {
+	case fv.Kind() == reflect.Slice || fv.Kind() == reflect.Array:
+		if fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() == reflect.Uint8 {
+			values.Add(name, string(fv.Bytes()))
+			return nil
+		}
+
+		sep := ""
+		switch {
+		case opts.has("comma"):
+			sep = ","
+		case opts.has("space"):
+			sep = " "
+		case opts.has("semicolon"):
+			sep = ";"
+		case opts.has("brackets"):
+			name += "[]"
+		}
+
+		items := make([]string, 0, fv.Len())
+		for i := 0; i < fv.Len(); i++ {
+			items = append(items, formatQueryValue(fv.Index(i), opts, layout))
+		}
+		if sep != "" {
+			values.Add(name, strings.Join(items, sep))
+			return nil
+		}
+		for _, item := range items {
+			values.Add(name, item)
+		}
+		return nil
+	case fv.Kind() == reflect.Struct && fv.Type() != timeType:
+		return encodeStruct(values, fv, name)
+	}
+
+	values.Add(name, formatQueryValue(fv, opts, layout))
+	return nil
+}
+
+func formatQueryValue(v reflect.Value, opts queryTagOptions, layout string) string {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+
+	if v.Type() == timeType {
+		t := v.Interface().(time.Time)
+		switch {
+		case opts.has("unix"):
+			return strconv.FormatInt(t.Unix(), 10)
+		case opts.has("unixmilli"):
+			return strconv.FormatInt(t.UnixNano()/int64(time.Millisecond), 10)
+		case opts.has("unixnano"):
+			return strconv.FormatInt(t.UnixNano(), 10)
+		case layout != "":
+			return t.Format(layout)
+		}
+		return t.Format(time.RFC3339)
+	}
+
+	if v.Kind() == reflect.Bool && opts.has("int") {
+		if v.Bool() {
+			return "1"
+		}
+		return "0"
+	}
+
+	return fmt.Sprint(v.Interface())
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+
+	if v.Type() == timeType {
+		return v.Interface().(time.Time).IsZero()
+	}
+	return false
+}
+
+type queryTagOptions []string
+
+func parseQueryTag(tag string) (string, queryTagOptions) {
+	parts := strings.Split(tag, ",")
+	return parts[0], parts[1:]
+}
+
+func (o queryTagOptions) has(opt string) bool {
+	for _, s := range o {
+		if s == opt {
+			return true
+		}
+	}
+	return false
+}
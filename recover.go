@@ -0,0 +1,50 @@
+package go_http_client
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+)
+
+// PanicError is returned when a request option, a response validator or a
+// response parser panicked, the panic is recovered so it doesn't take the
+// caller down
+type PanicError struct {
+	// In is the kind of function which panicked, e.g. "response parser"
+	In    string
+	Value interface{}
+	Stack []byte
+}
+
+func (t PanicError) Error() string {
+	return fmt.Sprintf("%v panicked: %v", t.In, t.Value)
+}
+
+// Unwrap returns the panic value when it is an error
+func (t PanicError) Unwrap() error {
+	err, _ := t.Value.(error)
+	return err
+}
+
+// recoverPanic turn a panic into a PanicError stored in err, it must be
+// deferred directly
+func recoverPanic(in string, err *error) {
+	if v := recover(); v != nil {
+		*err = PanicError{In: in, Value: v, Stack: debug.Stack()}
+	}
+}
+
+func applyOption(opt RequestOption, req *http.Request) (err error) {
+	defer recoverPanic("request option", &err)
+	return opt(req)
+}
+
+func validate(v ValidateResponse, resp *http.Response) (err error) {
+	defer recoverPanic("response validator", &err)
+	return v(resp)
+}
+
+func parse(parser ResponseParser, resp *http.Response) (err error) {
+	defer recoverPanic("response parser", &err)
+	return parser(resp)
+}
@@ -0,0 +1,379 @@
+package go_http_client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// redactedValue replaces redacted values in debug dumps
+const redactedValue = "[REDACTED]"
+
+// DefaultRedactedHeaders are the headers whose values are replaced in debug
+// dumps unless WithoutHeaderRedaction is set
+var DefaultRedactedHeaders = []string{
+	"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie",
+	"X-Api-Key", "Api-Key", "X-Auth-Token",
+}
+
+// DefaultMaskedQueryParams are the query parameters whose values are masked
+// in debug dumps, errors and telemetry, matched case insensitively
+var DefaultMaskedQueryParams = []string{
+	"access_token", "token", "api_key", "apikey", "password", "secret", "client_secret", "signature",
+}
+
+// WithMaskedQueryParams mask the values of the given query parameters in
+// debug dumps, error messages and the URLs reported to hooks and telemetry
+// through RedactedURL, on top of DefaultMaskedQueryParams
+func WithMaskedQueryParams(names ...string) Option {
+	return func(c *Client) {
+		for _, name := range names {
+			c.redactor.query[strings.ToLower(name)] = true
+		}
+	}
+}
+
+// WithJSONRedaction redact the values matched by the rules in the JSON
+// request and response bodies of debug dumps. Rules are JSONPath style dotted
+// paths: "$.password", "$.card.number", "$.items[*].token" or "$.*.secret"
+func WithJSONRedaction(rules ...string) Option {
+	return func(c *Client) {
+		for _, rule := range rules {
+			c.redactor.jsonRules = append(c.redactor.jsonRules, parseJSONRule(rule))
+		}
+	}
+}
+
+// WithRedactedHeaders redact the values of the given headers in debug dumps,
+// on top of DefaultRedactedHeaders
+func WithRedactedHeaders(names ...string) Option {
+	return func(c *Client) {
+		for _, name := range names {
+			c.redactor.headers[http.CanonicalHeaderKey(name)] = true
+		}
+	}
+}
+
+// WithoutHeaderRedaction dump all header values, including credentials.
+// Headers added with WithRedactedHeaders afterwards are redacted again
+func WithoutHeaderRedaction() Option {
+	return func(c *Client) {
+		c.redactor.headers = make(map[string]bool)
+	}
+}
+
+// redactor renders the debug dumps with the secrets hidden
+type redactor struct {
+	headers map[string]bool
+	// query holds lower case parameter names
+	query map[string]bool
+	// jsonRules are the paths of the JSON body values to redact
+	jsonRules [][]string
+	// bodyLimit is the amount of a body dumped, 0 for no limit
+	bodyLimit int64
+	// formatter renders the dumps, httputil style when nil
+	formatter DumpFormatter
+}
+
+func newRedactor() *redactor {
+	r := &redactor{headers: make(map[string]bool), query: make(map[string]bool)}
+	for _, name := range DefaultRedactedHeaders {
+		r.headers[http.CanonicalHeaderKey(name)] = true
+	}
+	for _, name := range DefaultMaskedQueryParams {
+		r.query[name] = true
+	}
+	return r
+}
+
+func (r *redactor) clone() *redactor {
+	return &redactor{
+		headers:   copyMap(r.headers),
+		query:     copyMap(r.query),
+		jsonRules: append([][]string(nil), r.jsonRules...),
+		bodyLimit: r.bodyLimit,
+		formatter: r.formatter,
+	}
+}
+
+type redactorKey struct{}
+
+func redactorOf(ctx context.Context) *redactor {
+	if r, ok := ctx.Value(redactorKey{}).(*redactor); ok {
+		return r
+	}
+	return newRedactor()
+}
+
+// RedactedURL returns the URL of a request made by the client without the
+// password and with the masked query parameter values replaced, see
+// WithMaskedQueryParams. It is meant for hooks and telemetry
+func RedactedURL(req *http.Request) string {
+	return redactorOf(req.Context()).url(req.URL).String()
+}
+
+// url returns a copy of u without the password and the masked query values
+func (r *redactor) url(u *url.URL) *url.URL {
+	masked := *u
+	if _, has := u.User.Password(); has {
+		masked.User = url.UserPassword(u.User.Username(), "xxxxx")
+	}
+	if u.RawQuery == "" {
+		return &masked
+	}
+
+	params := strings.Split(u.RawQuery, "&")
+	for i, param := range params {
+		rawName, _, _ := strings.Cut(param, "=")
+		name := rawName
+		if unescaped, err := url.QueryUnescape(rawName); err == nil {
+			name = unescaped
+		}
+		if r.query[strings.ToLower(name)] {
+			params[i] = rawName + "=" + redactedValue
+		}
+	}
+	masked.RawQuery = strings.Join(params, "&")
+	return &masked
+}
+
+func (r *redactor) header(h http.Header) http.Header {
+	out := h.Clone()
+	for name, vs := range out {
+		if r.headers[name] {
+			redacted := make([]string, len(vs))
+			for i := range redacted {
+				redacted[i] = redactedValue
+			}
+			out[name] = redacted
+		}
+	}
+	return out
+}
+
+// redactURLError mask the URL in a transport error of req
+func redactURLError(req *http.Request, err error) error {
+	if urlErr, ok := err.(*url.Error); ok {
+		masked := *urlErr
+		masked.URL = RedactedURL(req)
+		return &masked
+	}
+	return err
+}
+
+// dumpRequest dump req with the secrets redacted, the body is read and
+// replaced as by httputil.DumpRequestOut
+func (r *redactor) dumpRequest(req *http.Request) ([]byte, error) {
+	body, err := r.dumpBody(req.Header, req.ContentLength, &req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	dumped := *req
+	dumped.Header = r.header(req.Header)
+	dumped.URL = r.url(req.URL)
+	head, err := httputil.DumpRequestOut(&dumped, false)
+	if err != nil {
+		return nil, err
+	}
+	return append(head, body...), nil
+}
+
+// dumpResponse dump resp with the secrets redacted, the body is read and
+// replaced as by httputil.DumpResponse
+func (r *redactor) dumpResponse(resp *http.Response) ([]byte, error) {
+	body, err := r.dumpBody(resp.Header, resp.ContentLength, &resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	dumped := *resp
+	dumped.Header = r.header(resp.Header)
+	head, err := httputil.DumpResponse(&dumped, false)
+	if err != nil {
+		return nil, err
+	}
+	return append(head, body...), nil
+}
+
+// requestDump returns the structured dump of req passed to a DumpFormatter
+func (r *redactor) requestDump(req *http.Request) (Dump, error) {
+	body, err := r.dumpBody(req.Header, req.ContentLength, &req.Body)
+	return Dump{
+		Method:    req.Method,
+		URL:       r.url(req.URL).String(),
+		Header:    r.header(req.Header),
+		Body:      body,
+		Tags:      TagsFromContext(req.Context()),
+		RequestID: RequestIDFromContext(req.Context()),
+	}, err
+}
+
+// responseDump returns the structured dump of resp passed to a DumpFormatter
+func (r *redactor) responseDump(resp *http.Response, d time.Duration) (Dump, error) {
+	body, err := r.dumpBody(resp.Header, resp.ContentLength, &resp.Body)
+	dump := Dump{
+		Status:   resp.StatusCode,
+		Header:   r.header(resp.Header),
+		Body:     body,
+		Duration: d,
+		Tags:     Tags{},
+	}
+	if resp.Request != nil {
+		dump.Method, dump.URL = resp.Request.Method, r.url(resp.Request.URL).String()
+		dump.Tags = TagsFromContext(resp.Request.Context())
+		dump.RequestID = RequestIDFromContext(resp.Request.Context())
+	}
+	return dump, err
+}
+
+// dumpBody returns the body as dumped and replace it with a reader of the
+// same content. Only the dumped part is read, streaming bodies aren't read at
+// all and with a debug body limit neither are binary ones
+func (r *redactor) dumpBody(h http.Header, size int64, body *io.ReadCloser) ([]byte, error) {
+	if *body == nil || *body == http.NoBody {
+		return nil, nil
+	}
+
+	mt := mediaType(h)
+	if isStreamingMediaType(mt) {
+		return streamingBody(mt), nil
+	}
+	if r.bodyLimit > 0 && mt != "" && !isTextMediaType(mt) {
+		return binaryBody(mt, size), nil
+	}
+
+	limit := r.bodyLimit
+	if limit <= 0 {
+		limit = DefaultDebugBodyBytes
+	}
+	data, truncated, err := readBody(body, limit)
+	if err != nil {
+		return nil, err
+	}
+	if r.bodyLimit > 0 && mt == "" {
+		if sniffed := mediaType(http.Header{"Content-Type": {http.DetectContentType(data)}}); !isTextMediaType(sniffed) {
+			return binaryBody(sniffed, size), nil
+		}
+	}
+
+	if len(r.jsonRules) > 0 && isJSONMediaType(mt) {
+		if truncated {
+			// a cut document can't be parsed, hence redacted
+			return []byte(fmt.Sprintf("[JSON body over %v bytes withheld]", limit)), nil
+		}
+		data = r.redactJSONBody(data)
+	}
+	if truncated {
+		data = append(data, debugBodyTruncated...)
+	}
+	return data, nil
+}
+
+// readBody read up to limit bytes of the body, all of it when limit is 0,
+// and replace it with a reader of the whole content. A read error is also
+// returned by the replacement once the data read before it is consumed
+func readBody(body *io.ReadCloser, limit int64) ([]byte, bool, error) {
+	orig := *body
+	if limit <= 0 {
+		data, err := ioutil.ReadAll(orig)
+		orig.Close()
+		*body = replayBody(data, err)
+		return data, false, err
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(orig, limit+1))
+	if err != nil || int64(len(data)) <= limit {
+		orig.Close()
+		*body = replayBody(data, err)
+		return data, false, err
+	}
+	// the rest is still unread, stream it after what was read
+	*body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(data), orig), orig}
+	return data[:limit:limit], true, nil
+}
+
+// replayBody returns a body reading data and then failing with err, if any
+func replayBody(data []byte, err error) io.ReadCloser {
+	if err == nil {
+		return ioutil.NopCloser(bytes.NewReader(data))
+	}
+	return ioutil.NopCloser(io.MultiReader(bytes.NewReader(data), errReader{err}))
+}
+
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
+
+// redactJSONBody returns body with the JSON redaction rules applied, unchanged
+// when it isn't valid JSON
+func (r *redactor) redactJSONBody(body []byte) []byte {
+	if len(body) == 0 {
+		return body
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return body
+	}
+	for _, path := range r.jsonRules {
+		v = redactJSON(v, path)
+	}
+	redacted, err := json.Marshal(v)
+	if err != nil {
+		return body
+	}
+	return redacted
+}
+
+// parseJSONRule split a rule such as "$.card.number" or "$.items[*].token"
+// into its path segments, "*" matching any key or array element
+func parseJSONRule(rule string) []string {
+	rule = strings.TrimPrefix(strings.TrimPrefix(rule, "$"), ".")
+	rule = strings.ReplaceAll(rule, "[*]", ".*")
+	return strings.Split(rule, ".")
+}
+
+// redactJSON replace the values at path in v. Arrays met by a key segment
+// apply it to each of their elements, so "$.items.token" redacts the token
+// of every item
+func redactJSON(v interface{}, path []string) interface{} {
+	if len(path) == 0 {
+		return redactedValue
+	}
+
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, child := range t {
+			if path[0] == "*" || path[0] == k {
+				t[k] = redactJSON(child, path[1:])
+			}
+		}
+	case []interface{}:
+		rest := path
+		if path[0] == "*" {
+			rest = path[1:]
+		}
+		for i, child := range t {
+			t[i] = redactJSON(child, rest)
+		}
+	}
+	return v
+}
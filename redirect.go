@@ -0,0 +1,75 @@
+package go_http_client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// RedirectInfo describes a redirect response captured by WithCaptureRedirect
+type RedirectInfo struct {
+	StatusCode int
+	Status     string
+	Location   *url.URL
+	Header     http.Header
+}
+
+type captureRedirectKey struct{}
+
+// WithCaptureRedirect stop at the first redirect instead of following it and
+// treat it as success, filling dst. Useful for presigned URL or OAuth
+// authorize flows where the Location is the result. The response parser is
+// not called for a captured redirect
+func WithCaptureRedirect(dst *RedirectInfo) RequestOption {
+	return func(req *http.Request) (e error) {
+		if dst == nil || req == nil {
+			return fmt.Errorf("WithCaptureRedirect error: %v | %v", req, dst)
+		}
+		*req = *req.WithContext(context.WithValue(req.Context(), captureRedirectKey{}, dst))
+		return
+	}
+}
+
+func redirectCapture(ctx context.Context) *RedirectInfo {
+	dst, _ := ctx.Value(captureRedirectKey{}).(*RedirectInfo)
+	return dst
+}
+
+func isRedirect(code int) bool {
+	switch code {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	}
+	return false
+}
+
+// noFollow returns a copy of the http client which doesn't follow redirects,
+// custom doers are expected to handle this themselves
+func noFollow(client httpClient) httpClient {
+	hc, ok := client.(*http.Client)
+	if !ok {
+		return client
+	}
+	cp := *hc
+	cp.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	return &cp
+}
+
+func captureRedirect(dst *RedirectInfo, resp *http.Response) error {
+	location, err := resp.Location()
+	if err != nil {
+		return fmt.Errorf("failed to capture redirect: %w", err)
+	}
+
+	*dst = RedirectInfo{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Location:   location,
+		Header:     resp.Header,
+	}
+	return nil
+}
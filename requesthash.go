@@ -0,0 +1,86 @@
+package go_http_client
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// WithRequestHash send the canonical hash of every request in header, e.g.
+// "X-Request-Hash", for upstream replay detection. The hash is computed once
+// and reused by retries. Requests with a streamed body fail with
+// ErrStreamedBody, see CanonicalRequestHash
+func WithRequestHash(header string) Option {
+	return func(c *Client) {
+		c.requestHashHeader = header
+	}
+}
+
+// ErrStreamedBody is returned by CanonicalRequestHash for the bodies streamed
+// from their source, e.g. WithMultipartStreamOpt, which would be read whole
+// to be hashed
+var ErrStreamedBody = errors.New("streamed request body can't be hashed")
+
+// CanonicalRequestHash returns the hex SHA-256 of the method, path, query
+// sorted by key and value and the SHA-256 of the body, separated by newlines.
+// The body is hashed as it is read through GetBody. A body which can't be
+// rewound is buffered whole in memory so it can still be sent, and streamed
+// bodies are refused with ErrStreamedBody
+func CanonicalRequestHash(req *http.Request) (string, error) {
+	bodyHash := sha256.New()
+	if hasBody(req) {
+		if isStreamedBody(req) {
+			return "", ErrStreamedBody
+		}
+		if req.GetBody == nil {
+			data, err := ioutil.ReadAll(req.Body)
+			req.Body.Close()
+			if err != nil {
+				return "", err
+			}
+			req.Body = ioutil.NopCloser(bytes.NewReader(data))
+			req.GetBody = func() (io.ReadCloser, error) {
+				return ioutil.NopCloser(bytes.NewReader(data)), nil
+			}
+		}
+
+		r, err := req.GetBody()
+		if err != nil {
+			return "", err
+		}
+		defer r.Close()
+		if _, err := io.Copy(bodyHash, r); err != nil {
+			return "", err
+		}
+	}
+
+	canonical := strings.Join([]string{
+		strings.ToUpper(req.Method),
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		hex.EncodeToString(bodyHash.Sum(nil)),
+	}, "\n")
+
+	sum := sha256.Sum256([]byte(canonical))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func canonicalQuery(query url.Values) string {
+	keys := sortedKeys(query)
+	pairs := make([]string, 0, len(query))
+	for _, k := range keys {
+		vs := append([]string(nil), query[k]...)
+		sort.Strings(vs)
+		for _, v := range vs {
+			pairs = append(pairs, url.QueryEscape(k)+"="+url.QueryEscape(v))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
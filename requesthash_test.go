@@ -0,0 +1,28 @@
+package go_http_client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRequestHashRefusesStreamedBody(t *testing.T) {
+	opened := false
+	b := NewMultipartBuilder().Stream("file", "data.bin", func() (io.ReadCloser, error) {
+		opened = true
+		return ioutil.NopCloser(strings.NewReader("payload")), nil
+	}, 7)
+	c := NewClient("http://api.example.com", WithRequestHash("X-Request-Hash"))
+
+	_, err := c.PrepareRequest(context.Background(), http.MethodPost, "/upload", WithMultipartStreamOpt(b))
+	if !errors.Is(err, ErrStreamedBody) {
+		t.Fatalf("err = %v, want ErrStreamedBody", err)
+	}
+	if opened {
+		t.Error("streamed body read to be hashed")
+	}
+}
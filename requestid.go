@@ -0,0 +1,107 @@
+package go_http_client
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+)
+
+type requestIDKey struct{}
+
+type correlationIDKey struct{}
+
+// WithRequestID attach an ID generated by gen to every request in the
+// headerName header, e.g. "X-Request-Id", so client and server logs can be
+// correlated. A nil gen generates random 128 bit hex IDs. Retries keep the ID
+// and a header set by a request option is kept as is. The ID is added to the
+// logs, StatusCodeError and transport errors, and hooks read it with
+// RequestIDFromContext
+func WithRequestID(headerName string, gen func() string) Option {
+	return func(c *Client) {
+		if gen == nil {
+			gen = newRequestID
+		}
+		c.requestIDHeader = headerName
+		c.requestIDGen = gen
+	}
+}
+
+// RequestIDFromContext returns the ID the client attached to a request, see
+// WithRequestID, given its context
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// WithCorrelationID forward the correlation ID stored in the request context
+// under key as the headerName header, so the IDs assigned by an inbound
+// middleware flow to the downstream calls. The value must be a string or a
+// fmt.Stringer, a nil key reads the ID set with ContextWithCorrelationID. A
+// header set by a request option is kept, and when headerName is also the
+// WithRequestID header the forwarded ID is used as the request ID
+func WithCorrelationID(headerName string, key interface{}) Option {
+	return func(c *Client) {
+		if key == nil {
+			key = correlationIDKey{}
+		}
+		c.correlationHeader = headerName
+		c.correlationKey = key
+	}
+}
+
+// ContextWithCorrelationID store the correlation ID forwarded by the clients
+// set up with WithCorrelationID and a nil key
+func ContextWithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationIDKey{}, id)
+}
+
+func (c *Client) setCorrelationID(req *http.Request) {
+	if c.correlationHeader == "" || req.Header.Get(c.correlationHeader) != "" {
+		return
+	}
+
+	var id string
+	switch v := req.Context().Value(c.correlationKey).(type) {
+	case string:
+		id = v
+	case fmt.Stringer:
+		id = v.String()
+	}
+	if id != "" {
+		req.Header.Set(c.correlationHeader, id)
+	}
+}
+
+func newRequestID() string {
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(id[:])
+}
+
+func (c *Client) setRequestID(req *http.Request) {
+	if c.requestIDHeader == "" {
+		return
+	}
+
+	id := req.Header.Get(c.requestIDHeader)
+	if id == "" {
+		id = c.requestIDGen()
+	}
+	if id == "" {
+		return
+	}
+	req.Header.Set(c.requestIDHeader, id)
+	*req = *req.WithContext(context.WithValue(req.Context(), requestIDKey{}, id))
+}
+
+// withRequestID prefix err, a failure to get a response, with the request ID
+func withRequestID(req *http.Request, err error) error {
+	if id := RequestIDFromContext(req.Context()); id != "" {
+		return fmt.Errorf("request %v: %w", id, err)
+	}
+	return err
+}
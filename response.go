@@ -0,0 +1,76 @@
+package go_http_client
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Response holds the metadata of a response, e.g. pagination or rate limit
+// headers, see DoRequestMeta
+type Response struct {
+	StatusCode int
+	Status     string
+	Header     http.Header
+	RequestURL *url.URL
+	// Duration is the time until the response headers were received
+	Duration time.Duration
+	// Attempts is the number of requests sent to get this response
+	Attempts int
+	// Timings are the phase durations of the last attempt
+	Timings Timings
+}
+
+func newResponse(req *http.Request, resp *http.Response, d time.Duration) *Response {
+	return &Response{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Header:     resp.Header,
+		RequestURL: req.URL,
+		Duration:   d,
+		Attempts:   1,
+	}
+}
+
+// RequestIDHeaders are the response headers looked up, in order, for the
+// request ID passed in ResponseMetadata and StatusCodeError
+var RequestIDHeaders = []string{"X-Request-Id", "X-Correlation-Id", "Request-Id"}
+
+// ResponseMetadata is the provenance of a decoded response
+type ResponseMetadata struct {
+	StatusCode int
+	Header     http.Header
+	ETag       string
+	RequestID  string
+}
+
+// ResponseMetadataSetter is implemented by decode targets which want to carry
+// the metadata of the response they were decoded from. The setter is called
+// by the decoding parsers after a successful decode
+type ResponseMetadataSetter interface {
+	SetResponseMetadata(ResponseMetadata)
+}
+
+func setResponseMetadata(dst interface{}, resp *http.Response) {
+	setter, ok := dst.(ResponseMetadataSetter)
+	if !ok {
+		return
+	}
+
+	meta := ResponseMetadata{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		ETag:       resp.Header.Get("ETag"),
+		RequestID:  requestID(resp.Header),
+	}
+	setter.SetResponseMetadata(meta)
+}
+
+func requestID(h http.Header) string {
+	for _, name := range RequestIDHeaders {
+		if id := h.Get(name); id != "" {
+			return id
+		}
+	}
+	return ""
+}
@@ -0,0 +1,13 @@
+module github.com/Traumeel/go-http-client/sentry
+
+go 1.25.0
+
+require (
+	github.com/Traumeel/go-http-client v0.1.0
+	github.com/getsentry/sentry-go v0.49.0
+)
+
+require (
+	golang.org/x/sys v0.46.0 // indirect
+	golang.org/x/text v0.39.0 // indirect
+)
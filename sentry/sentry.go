@@ -0,0 +1,119 @@
+// Package sentry records the requests of the client as Sentry breadcrumbs
+// and reports the failed ones. It lives in its own module so sentry-go is
+// only pulled in by the users who need it
+package sentry
+
+import (
+	"net/http"
+	"net/url"
+
+	cl "github.com/Traumeel/go-http-client"
+	sentrygo "github.com/getsentry/sentry-go"
+)
+
+type Option func(*config)
+
+type config struct {
+	capture func(error) bool
+}
+
+// WithCaptureFilter report the errors for which capture returns true instead
+// of 5xx responses and timeouts
+func WithCaptureFilter(capture func(error) bool) Option {
+	return func(c *config) {
+		c.capture = capture
+	}
+}
+
+func captureDefault(err error) bool {
+	switch cl.ClassifyError(err) {
+	case cl.ErrorClassServer, cl.ErrorClassTimeout:
+		return true
+	}
+	return false
+}
+
+// WithSentry add a breadcrumb for each request to the hub of the request
+// context, or the current hub, and capture the terminal errors of requests
+// which failed with a 5xx or timed out, with the request attached
+func WithSentry(options ...Option) cl.Option {
+	cfg := config{capture: captureDefault}
+	for _, opt := range options {
+		opt(&cfg)
+	}
+
+	onResponse := func(req *http.Request, resp *cl.Response) {
+		level := sentrygo.LevelInfo
+		if resp.StatusCode >= 400 {
+			level = sentrygo.LevelWarning
+		}
+		breadcrumb(req, level, map[string]interface{}{
+			"status_code": resp.StatusCode,
+			"duration_ms": resp.Duration.Milliseconds(),
+			"attempts":    resp.Attempts,
+		})
+	}
+
+	onError := func(req *http.Request, resp *cl.Response, err error) {
+		if resp == nil {
+			breadcrumb(req, sentrygo.LevelError, map[string]interface{}{
+				"reason": err.Error(),
+			})
+		}
+		if !cfg.capture(err) {
+			return
+		}
+
+		hub(req).WithScope(func(scope *sentrygo.Scope) {
+			scope.SetRequest(sanitized(req))
+			scope.SetTags(cl.TagsFromContext(req.Context()))
+			scope.SetTag("http.method", req.Method)
+			scope.SetTag("http.host", req.URL.Host)
+			scope.SetTag("error.class", cl.ClassifyError(err).String())
+			if op := cl.OperationFromContext(req.Context()); op != "" {
+				scope.SetTag("operation", op)
+			}
+			if resp != nil {
+				scope.SetTag("http.status_code", resp.Status)
+			}
+			hub(req).CaptureException(err)
+		})
+	}
+
+	return func(c *cl.Client) {
+		cl.OnResponse(onResponse)(c)
+		cl.OnError(onError)(c)
+	}
+}
+
+func hub(req *http.Request) *sentrygo.Hub {
+	if h := sentrygo.GetHubFromContext(req.Context()); h != nil {
+		return h
+	}
+	return sentrygo.CurrentHub()
+}
+
+func breadcrumb(req *http.Request, level sentrygo.Level, data map[string]interface{}) {
+	data["method"] = req.Method
+	data["url"] = cl.RedactedURL(req)
+	hub(req).AddBreadcrumb(&sentrygo.Breadcrumb{
+		Type:     "http",
+		Category: "http",
+		Level:    level,
+		Data:     data,
+	}, nil)
+}
+
+// sanitized returns a copy of req without the credentials in its headers and
+// URL, the request is attached to the reported event
+func sanitized(req *http.Request) *http.Request {
+	r := req.Clone(req.Context())
+	if u, err := url.Parse(cl.RedactedURL(req)); err == nil {
+		r.URL = u
+	}
+	r.URL.User = nil
+	for _, h := range []string{"Authorization", "Proxy-Authorization", "Cookie"} {
+		r.Header.Del(h)
+	}
+	return r
+}
@@ -0,0 +1,59 @@
+package go_http_client
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrClientClosed is returned for requests started after Shutdown
+var ErrClientClosed = errors.New("client is shut down")
+
+// Flusher is implemented by sinks buffering data, such as a ContractRecorder
+// sending interactions in the background, a HARRecorder saving to a file or
+// an Archiver, to be flushed on Shutdown
+type Flusher interface {
+	Flush(ctx context.Context) error
+}
+
+type idleCloser interface {
+	CloseIdleConnections()
+}
+
+// Shutdown stop accepting new requests, wait for the requests in flight to
+// finish, including the ones waiting for a retry, then flush the contract
+// recorder if it is a Flusher, the HAR recorder and the archivers, close the
+// archivers and close the idle connections of the http client. Archivers
+// shared with other clients are closed as well. The client and its clones are
+// unusable afterwards. If ctx is done first Shutdown returns its error,
+// leaving the remaining requests running. Otherwise every sink is flushed
+// and the first failure is returned
+func (c *Client) Shutdown(ctx context.Context) error {
+	select {
+	case <-c.journal.close():
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	var firstErr error
+	keep := func(err error) {
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	if f, ok := c.contractRecorder.(Flusher); ok {
+		keep(f.Flush(ctx))
+	}
+	if c.har != nil {
+		keep(c.har.Flush(ctx))
+	}
+	for _, a := range c.archives {
+		keep(a.Flush(ctx))
+		keep(a.Close())
+	}
+
+	if ic, ok := c.httpClient.(idleCloser); ok {
+		ic.CloseIdleConnections()
+	}
+	return firstErr
+}
@@ -0,0 +1,70 @@
+package go_http_client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestShutdownPersistsSinks(t *testing.T) {
+	srv := attributionServer(t)
+	dir := t.TempDir()
+	a, err := NewArchiver(ArchiveConfig{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	harPath := filepath.Join(dir, "client.har")
+	c := NewClient(srv.URL, WithArchive("", a), WithHARRecorder(NewHARFileRecorder(harPath, 0)))
+
+	var out map[string]interface{}
+	if err := c.GetJson(context.Background(), "/users/42", &out); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(harPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var har struct {
+		Log struct {
+			Entries []struct {
+				Request struct {
+					URL string `json:"url"`
+				} `json:"request"`
+			} `json:"entries"`
+		} `json:"log"`
+	}
+	if err := json.Unmarshal(data, &har); err != nil {
+		t.Fatal(err)
+	}
+	if len(har.Log.Entries) != 1 || har.Log.Entries[0].Request.URL != srv.URL+"/users/42" {
+		t.Fatalf("har entries = %+v", har.Log.Entries)
+	}
+
+	f, err := os.Open(filepath.Join(dir, archiveFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var records []archiveRecord
+	for sc := bufio.NewScanner(f); sc.Scan(); {
+		var rec archiveRecord
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, rec)
+	}
+	if len(records) != 1 || records[0].Status != 200 {
+		t.Fatalf("archive records = %+v", records)
+	}
+
+	if err := a.write([]byte("{}\n")); err == nil {
+		t.Fatal("archiver still open after Shutdown")
+	}
+}
@@ -0,0 +1,62 @@
+//go:build go1.21
+
+package go_http_client
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+)
+
+// WithSlog log with the structured standard library logger l, the client
+// log levels map to the slog levels and the fields to attributes
+func WithSlog(l *slog.Logger) Option {
+	return WithLog(NewSlogLogger(l))
+}
+
+// NewSlogLogger returns a Logger writing to l
+func NewSlogLogger(l *slog.Logger) Logger {
+	return slogLogger{l: l}
+}
+
+type slogLogger struct {
+	l *slog.Logger
+}
+
+func (t slogLogger) WithFields(fields Fields) Logger {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	args := make([]any, 0, 2*len(fields))
+	for _, k := range keys {
+		args = append(args, k, fields[k])
+	}
+	return slogLogger{l: t.l.With(args...)}
+}
+
+func (t slogLogger) log(level slog.Level, format string, args []interface{}) {
+	ctx := context.Background()
+	if t.l.Enabled(ctx, level) {
+		t.l.Log(ctx, level, fmt.Sprintf(format, args...))
+	}
+}
+
+func (t slogLogger) Debugf(format string, args ...interface{}) {
+	t.log(slog.LevelDebug, format, args)
+}
+
+func (t slogLogger) Infof(format string, args ...interface{}) {
+	t.log(slog.LevelInfo, format, args)
+}
+
+func (t slogLogger) Warnf(format string, args ...interface{}) {
+	t.log(slog.LevelWarn, format, args)
+}
+
+func (t slogLogger) Errorf(format string, args ...interface{}) {
+	t.log(slog.LevelError, format, args)
+}
@@ -0,0 +1,231 @@
+// Package soap wraps requests in SOAP 1.1 or 1.2 envelopes and unwraps the
+// responses on top of go-http-client, decoding faults into a typed error.
+package soap
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	cl "github.com/Traumeel/go-http-client"
+)
+
+// Version selects the envelope namespace and the way the action is sent
+type Version int
+
+const (
+	// V11 is SOAP 1.1, the action goes in the SOAPAction header
+	V11 Version = iota
+	// V12 is SOAP 1.2, the action is a parameter of the Content-Type
+	V12
+)
+
+const (
+	Namespace11 = "http://schemas.xmlsoap.org/soap/envelope/"
+	Namespace12 = "http://www.w3.org/2003/05/soap-envelope"
+
+	ContentType11 = "text/xml"
+	ContentType12 = "application/soap+xml"
+)
+
+func (v Version) namespace() string {
+	if v == V12 {
+		return Namespace12
+	}
+	return Namespace11
+}
+
+// Fault is a SOAP fault returned by the service. The fields of both versions
+// are mapped to the same names, Subcode, Node and Role are SOAP 1.2 only
+type Fault struct {
+	Code    string
+	Subcode string
+	Reason  string
+	// Actor is the faultactor of SOAP 1.1
+	Actor string
+	Node  string
+	Role  string
+	// Detail is the raw XML inside the detail element
+	Detail []byte
+}
+
+func (t *Fault) Error() string {
+	return fmt.Sprintf("soap fault: %v | %v", t.Code, t.Reason)
+}
+
+// DecodeDetail decode the fault detail into v
+func (t *Fault) DecodeDetail(v interface{}) error {
+	return xml.Unmarshal(t.Detail, v)
+}
+
+type rawXML struct {
+	Inner []byte `xml:",innerxml"`
+}
+
+type fault struct {
+	// SOAP 1.1
+	FaultCode   string `xml:"faultcode"`
+	FaultString string `xml:"faultstring"`
+	FaultActor  string `xml:"faultactor"`
+	LowerDetail rawXML `xml:"detail"`
+
+	// SOAP 1.2
+	Code struct {
+		Value   string `xml:"Value"`
+		Subcode struct {
+			Value string `xml:"Value"`
+		} `xml:"Subcode"`
+	} `xml:"Code"`
+	Reason struct {
+		Text []string `xml:"Text"`
+	} `xml:"Reason"`
+	Node   string `xml:"Node"`
+	Role   string `xml:"Role"`
+	Detail rawXML `xml:"Detail"`
+}
+
+func (f *fault) toFault() *Fault {
+	if f.FaultCode != "" || f.FaultString != "" {
+		return &Fault{Code: f.FaultCode, Reason: f.FaultString, Actor: f.FaultActor, Detail: f.LowerDetail.Inner}
+	}
+
+	return &Fault{
+		Code:    f.Code.Value,
+		Subcode: f.Code.Subcode.Value,
+		Reason:  strings.Join(f.Reason.Text, "; "),
+		Node:    f.Node,
+		Role:    f.Role,
+		Detail:  f.Detail.Inner,
+	}
+}
+
+type envelope struct {
+	Body struct {
+		Fault *fault `xml:"Fault"`
+		Inner []byte `xml:",innerxml"`
+	} `xml:"Body"`
+}
+
+// Client sends SOAP requests to the endpoint path of the underlying client
+type Client struct {
+	*cl.Client
+	path    string
+	version Version
+}
+
+func NewClient(c *cl.Client, path string, version Version) *Client {
+	return &Client{Client: c, path: path, version: version}
+}
+
+// Call send in as the body of an envelope for action and decode the body of
+// the response envelope into out, which may be nil. A fault is returned as
+// *Fault, whatever the status code of the response
+func (c *Client) Call(ctx context.Context, action string, in, out interface{}, options ...cl.RequestOption) error {
+	return c.Do(ctx, action, nil, in, out, options...)
+}
+
+// Do send a request like Call with header, when not nil, as the content of the
+// envelope header
+func (c *Client) Do(ctx context.Context, action string, header, in, out interface{}, options ...cl.RequestOption) error {
+	body, err := Wrap(c.version, header, in)
+	if err != nil {
+		return err
+	}
+
+	h := http.Header{}
+	switch c.version {
+	case V12:
+		params := map[string]string{"charset": "utf-8"}
+		if action != "" {
+			params["action"] = action
+		}
+		h.Set("Content-Type", mime.FormatMediaType(ContentType12, params))
+	default:
+		h.Set("Content-Type", ContentType11+"; charset=utf-8")
+		h.Set("SOAPAction", `"`+action+`"`)
+	}
+
+	opts := append([]cl.RequestOption{cl.WithBodyOpt(bytes.NewReader(body)), cl.WithHeadersOpt(h)}, options...)
+	err = c.DoRequest(ctx, http.MethodPost, c.path, Parser(out), opts...)
+
+	// faults usually come with a 500, their body ends up in the status error
+	var statusErr cl.StatusCodeError
+	if errors.As(err, &statusErr) {
+		if f := faultOf(statusErr.RawBody); f != nil {
+			return f
+		}
+	}
+	return err
+}
+
+// Wrap encode header and body in an envelope of the given version, header
+// may be nil
+func Wrap(version Version, header, body interface{}) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	buf.WriteString(xml.Header)
+	fmt.Fprintf(buf, `<soap:Envelope xmlns:soap="%s">`, version.namespace())
+
+	if header != nil {
+		data, err := xml.Marshal(header)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal soap header: %w", err)
+		}
+		buf.WriteString("<soap:Header>")
+		buf.Write(data)
+		buf.WriteString("</soap:Header>")
+	}
+
+	buf.WriteString("<soap:Body>")
+	if body != nil {
+		data, err := xml.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal soap body: %w", err)
+		}
+		buf.Write(data)
+	}
+	buf.WriteString("</soap:Body></soap:Envelope>")
+	return buf.Bytes(), nil
+}
+
+// Unwrap decode the body of the envelope read from r into out, which may be
+// nil. A fault in the body is returned as *Fault
+func Unwrap(r io.Reader, out interface{}) error {
+	env := envelope{}
+	if err := xml.NewDecoder(r).Decode(&env); err != nil {
+		return fmt.Errorf("failed to decode soap envelope: %w", err)
+	}
+	if env.Body.Fault != nil {
+		return env.Body.Fault.toFault()
+	}
+	if out == nil || len(bytes.TrimSpace(env.Body.Inner)) == 0 {
+		return nil
+	}
+	if err := xml.Unmarshal(env.Body.Inner, out); err != nil {
+		return fmt.Errorf("failed to decode soap body: %w", err)
+	}
+	return nil
+}
+
+// Parser unwrap the response envelope into out, see Unwrap
+func Parser(out interface{}) cl.ResponseParser {
+	return func(resp *http.Response) (e error) {
+		if resp == nil {
+			return fmt.Errorf("soap.Parser function error: %v", resp)
+		}
+		return Unwrap(resp.Body, out)
+	}
+}
+
+func faultOf(body []byte) *Fault {
+	var fault *Fault
+	if errors.As(Unwrap(bytes.NewReader(body), nil), &fault) {
+		return fault
+	}
+	return nil
+}
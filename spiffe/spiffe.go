@@ -0,0 +1,94 @@
+// Package spiffe makes go-http-client present the workload SPIFFE identity
+// (X509-SVID) obtained from the SPIFFE Workload API as client certificate.
+// The SVID is rotated automatically by the Workload API source.
+package spiffe
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+
+	cl "github.com/Traumeel/go-http-client"
+	"github.com/spiffe/go-spiffe/v2/spiffetls/tlsconfig"
+	"github.com/spiffe/go-spiffe/v2/workloadapi"
+)
+
+type Option func(*config)
+
+type config struct {
+	authorizer tlsconfig.Authorizer
+	transport  *http.Transport
+}
+
+// WithServerAuthorizer verify the server certificate against the SPIFFE trust
+// bundles using authorizer instead of the system roots, for mesh internal
+// servers which present SVIDs themselves
+func WithServerAuthorizer(authorizer tlsconfig.Authorizer) Option {
+	return func(c *config) {
+		c.authorizer = authorizer
+	}
+}
+
+// WithTransport use a copy of t as the base transport instead of a copy of
+// http.DefaultTransport
+func WithTransport(t *http.Transport) Option {
+	return func(c *config) {
+		c.transport = t
+	}
+}
+
+// Source gives access to the rotating workload SVID
+type Source struct {
+	x509 *workloadapi.X509Source
+	cfg  config
+}
+
+// NewSource connect to the Workload API at socketPath, e.g.
+// "unix:///run/spire/sockets/agent.sock", and wait for the first SVID
+func NewSource(ctx context.Context, socketPath string, options ...Option) (*Source, error) {
+	cfg := config{}
+	for _, opt := range options {
+		opt(&cfg)
+	}
+
+	src, err := workloadapi.NewX509Source(ctx, workloadapi.WithClientOptions(workloadapi.WithAddr(socketPath)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create SPIFFE X509 source: %w", err)
+	}
+
+	return &Source{x509: src, cfg: cfg}, nil
+}
+
+// TLSConfig returns a client TLS config presenting the current SVID
+func (s *Source) TLSConfig() *tls.Config {
+	if s.cfg.authorizer != nil {
+		return tlsconfig.MTLSClientConfig(s.x509, s.x509, s.cfg.authorizer)
+	}
+	return &tls.Config{
+		MinVersion:           tls.VersionTLS12,
+		GetClientCertificate: tlsconfig.GetClientCertificate(s.x509),
+	}
+}
+
+// HTTPClient returns an http client using the SVID for client TLS
+func (s *Source) HTTPClient() *http.Client {
+	base := s.cfg.transport
+	if base == nil {
+		base = http.DefaultTransport.(*http.Transport)
+	}
+	t := base.Clone()
+	t.TLSClientConfig = s.TLSConfig()
+	return &http.Client{Transport: t}
+}
+
+// Close stop watching the Workload API
+func (s *Source) Close() error {
+	return s.x509.Close()
+}
+
+// WithSPIFFE send the requests of the client with the workload identity of
+// the source. The timeout of the client is not affected
+func WithSPIFFE(s *Source) cl.Option {
+	return cl.WithHttpClient(s.HTTPClient())
+}
@@ -0,0 +1,208 @@
+package go_http_client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MediaTypeEventStream is the media type of Server-Sent Events
+const MediaTypeEventStream = "text/event-stream"
+
+// maxEventLine bounds a single line of an event stream
+const maxEventLine = 1 << 20
+
+// Event is a Server-Sent Event
+type Event struct {
+	// ID is the last event ID of the stream, sent back as Last-Event-ID on
+	// reconnection
+	ID string
+	// Type is the event field, "message" when the server didn't set one
+	Type string
+	Data string
+}
+
+// Stream subscribe to the Server-Sent Events at path and call fn for every
+// event until ctx is done or fn returns an error, which Stream returns.
+// Dropped connections are reopened with Last-Event-ID after the retry delay
+// sent by the server or an increasing backoff. The stream ends without error
+// when the server answers 204 No Content, and with the error for any other
+// response the validator rejects with a 4xx. The client timeouts don't apply,
+// the stream is bound by ctx only
+func (c *Client) Stream(ctx context.Context, path string, fn func(Event) error, options ...RequestOption) error {
+	s := &eventStream{}
+	backoff := BackoffPolicy{Max: Duration(30 * time.Second), Jitter: true}
+	failures := 0
+
+	for {
+		received, err := c.streamOnce(ctx, path, s, fn, options)
+		var fatal fatalStreamError
+		switch {
+		case errors.As(err, &fatal):
+			return fatal.err
+		case errors.Is(err, ErrClientClosed):
+			return err
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case errors.Is(err, errStreamEnd):
+			return nil
+		}
+
+		var statusErr StatusCodeError
+		if errors.As(err, &statusErr) && statusErr.Code >= 400 && statusErr.Code < 500 && statusErr.Code != http.StatusTooManyRequests {
+			return err
+		}
+
+		if received {
+			failures = 0
+		}
+		failures++
+		wait := s.retry
+		if wait <= 0 {
+			wait = backoff.delay(failures, nil)
+		}
+		if err != nil {
+			withError(c.log, err).Debugf("event stream %v interrupted, reconnecting in %v", path, wait)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-c.clock.After(wait):
+		}
+	}
+}
+
+var errStreamEnd = errors.New("event stream closed by the server")
+
+// fatalStreamError ends the stream without reconnecting
+type fatalStreamError struct {
+	err error
+}
+
+func (t fatalStreamError) Error() string {
+	return t.err.Error()
+}
+
+// streamOnce read events from one connection, received reports whether any
+// event arrived
+func (c *Client) streamOnce(ctx context.Context, path string, s *eventStream, fn func(Event) error, options []RequestOption) (received bool, err error) {
+	header := http.Header{"Accept": {MediaTypeEventStream}, "Cache-Control": {"no-cache"}}
+	if s.lastID != "" {
+		header.Set("Last-Event-ID", s.lastID)
+	}
+	opts := append(append([]RequestOption{}, options...), WithHeadersOpt(header))
+
+	resp, err := c.DoRequestRaw(withoutTimeout(ctx), http.MethodGet, path, opts...)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNoContent {
+		return false, errStreamEnd
+	}
+	if mt, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mt != MediaTypeEventStream {
+		return false, fatalStreamError{fmt.Errorf("unexpected event stream content type: %q", mt)}
+	}
+
+	err = s.read(resp.Body, func(e Event) error {
+		received = true
+		if err := fn(e); err != nil {
+			return fatalStreamError{err}
+		}
+		return nil
+	})
+	return received, err
+}
+
+// eventStream is the state kept across the connections of a stream
+type eventStream struct {
+	lastID string
+	retry  time.Duration
+}
+
+// read parse the text/event-stream format from r, dispatching the events to
+// fn until r ends
+func (s *eventStream) read(r io.Reader, fn func(Event) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 4096), maxEventLine)
+	scanner.Split(scanEventLines)
+
+	data := strings.Builder{}
+	eventType := ""
+	hasData := false
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if hasData {
+				e := Event{ID: s.lastID, Type: eventType, Data: strings.TrimSuffix(data.String(), "\n")}
+				if e.Type == "" {
+					e.Type = "message"
+				}
+				if err := fn(e); err != nil {
+					return err
+				}
+			}
+			data.Reset()
+			eventType, hasData = "", false
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value := line, ""
+		if i := strings.IndexByte(line, ':'); i >= 0 {
+			field, value = line[:i], strings.TrimPrefix(line[i+1:], " ")
+		}
+		switch field {
+		case "event":
+			eventType = value
+		case "data":
+			data.WriteString(value)
+			data.WriteByte('\n')
+			hasData = true
+		case "id":
+			if !strings.ContainsRune(value, 0) {
+				s.lastID = value
+			}
+		case "retry":
+			if ms, err := strconv.Atoi(value); err == nil && ms >= 0 {
+				s.retry = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return io.ErrUnexpectedEOF
+}
+
+// scanEventLines split lines ended by CRLF, LF or CR
+func scanEventLines(data []byte, atEOF bool) (int, []byte, error) {
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		if data[i] == '\r' {
+			if i+1 == len(data) && !atEOF {
+				// the LF of a CRLF may still be on its way
+				return 0, nil, nil
+			}
+			if i+1 < len(data) && data[i+1] == '\n' {
+				return i + 2, data[:i], nil
+			}
+		}
+		return i + 1, data[:i], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
@@ -0,0 +1,291 @@
+package go_http_client
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultStatsWindow is the default length of a latency window, see
+// WithStatsWindow
+const DefaultStatsWindow = time.Minute
+
+// bucketsPerDoubling is the resolution of the histograms, each bucket is about
+// 19% wider than the previous one
+const bucketsPerDoubling = 4
+
+// LatencyBucket counts the requests which took at most UpperBound and more
+// than the bound of the previous bucket
+type LatencyBucket struct {
+	UpperBound time.Duration
+	Count      uint64
+}
+
+// LatencyHistogram is the latency distribution of an operation. Only the non
+// empty buckets are listed, in increasing order
+type LatencyHistogram struct {
+	Count uint64
+	// Errors counts the requests which got no response, they aren't part of
+	// the buckets
+	Errors uint64
+	// Statuses counts the responses by status class, "2xx" to "5xx"
+	Statuses map[string]uint64
+	// Attributions counts the requests, failed ones included, by their
+	// context attribution. Requests without one aren't counted
+	Attributions map[Attribution]uint64
+	Sum          time.Duration
+	Min          time.Duration
+	Max          time.Duration
+	Buckets      []LatencyBucket
+}
+
+// Mean returns the average latency
+func (h LatencyHistogram) Mean() time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	return h.Sum / time.Duration(h.Count)
+}
+
+// Quantile returns the upper bound of the bucket holding the q quantile, q
+// between 0 and 1, capped at Max
+func (h LatencyHistogram) Quantile(q float64) time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	rank := uint64(math.Ceil(q * float64(h.Count)))
+	seen := uint64(0)
+	for _, b := range h.Buckets {
+		seen += b.Count
+		if seen >= rank {
+			if b.UpperBound > h.Max {
+				return h.Max
+			}
+			return b.UpperBound
+		}
+	}
+	return h.Max
+}
+
+// WithStatsWindow set the length of the latency windows of Stats. Stats cover
+// the current and the previous window so they reflect between one and two
+// windows of recent traffic
+func WithStatsWindow(d time.Duration) Option {
+	return func(c *Client) {
+		c.stats.setWindow(d)
+	}
+}
+
+// Stats returns the recent latency histograms of the client and its clones,
+// keyed by operation name (see WithOperationOpt) or by method and path
+// template (see PathTemplate) for requests without one. Latency is measured
+// until the response headers, across all attempts
+func (c *Client) Stats() map[string]LatencyHistogram {
+	return c.stats.snapshot(c.clock.Now())
+}
+
+func statsKey(req *http.Request) string {
+	if op := OperationFromContext(req.Context()); op != "" {
+		return op
+	}
+	return req.Method + " " + PathTemplate(req.URL.Path)
+}
+
+// PathTemplate replace the path segments which look like identifiers,
+// numbers, UUIDs and long hex strings, with {id}, so "/users/42/orders"
+// becomes "/users/{id}/orders"
+func PathTemplate(path string) string {
+	segments := strings.Split(path, "/")
+	for i, seg := range segments {
+		if isIdentifier(seg) {
+			segments[i] = "{id}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func isIdentifier(seg string) bool {
+	if seg == "" {
+		return false
+	}
+	digits := true
+	for _, r := range seg {
+		switch {
+		case r >= '0' && r <= '9':
+		case r >= 'a' && r <= 'f', r >= 'A' && r <= 'F', r == '-':
+			digits = false
+		default:
+			return false
+		}
+	}
+	if digits {
+		return true
+	}
+	// UUIDs are 36 characters, other hex identifiers at least 16
+	if strings.Contains(seg, "-") {
+		return len(seg) == 36 && strings.Count(seg, "-") == 4
+	}
+	return len(seg) >= 16
+}
+
+type histogram struct {
+	count        uint64
+	errors       uint64
+	statuses     map[string]uint64
+	attributions map[Attribution]uint64
+	sum          time.Duration
+	min          time.Duration
+	max          time.Duration
+	buckets      map[int]uint64
+}
+
+func bucketIndex(d time.Duration) int {
+	if d < time.Microsecond {
+		return 0
+	}
+	return int(math.Ceil(math.Log2(float64(d)/float64(time.Microsecond)) * bucketsPerDoubling))
+}
+
+func bucketBound(i int) time.Duration {
+	return time.Duration(math.Pow(2, float64(i)/bucketsPerDoubling) * float64(time.Microsecond))
+}
+
+func (h *histogram) observe(d time.Duration) {
+	if h.count == 0 || d < h.min {
+		h.min = d
+	}
+	if d > h.max {
+		h.max = d
+	}
+	h.count++
+	h.sum += d
+	h.buckets[bucketIndex(d)]++
+}
+
+func (h *histogram) addTo(out *LatencyHistogram, counts map[int]uint64) {
+	if h.count > 0 {
+		if out.Count == 0 || h.min < out.Min {
+			out.Min = h.min
+		}
+		if h.max > out.Max {
+			out.Max = h.max
+		}
+	}
+	out.Count += h.count
+	out.Errors += h.errors
+	for class, n := range h.statuses {
+		if out.Statuses == nil {
+			out.Statuses = make(map[string]uint64)
+		}
+		out.Statuses[class] += n
+	}
+	for a, n := range h.attributions {
+		if out.Attributions == nil {
+			out.Attributions = make(map[Attribution]uint64)
+		}
+		out.Attributions[a] += n
+	}
+	out.Sum += h.sum
+	for i, n := range h.buckets {
+		counts[i] += n
+	}
+}
+
+type stats struct {
+	mu       sync.Mutex
+	window   time.Duration
+	started  time.Time
+	current  map[string]*histogram
+	previous map[string]*histogram
+}
+
+func newStats() *stats {
+	return &stats{
+		window:  DefaultStatsWindow,
+		current: make(map[string]*histogram),
+	}
+}
+
+func (s *stats) setWindow(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if d > 0 {
+		s.window = d
+	}
+}
+
+// rotate start a new window once the current one is over, dropping the
+// previous one. Windows which passed without traffic are dropped too
+func (s *stats) rotate(now time.Time) {
+	if s.started.IsZero() {
+		s.started = now
+	}
+	elapsed := now.Sub(s.started)
+	if elapsed < s.window {
+		return
+	}
+	s.previous = s.current
+	if elapsed >= 2*s.window {
+		s.previous = nil
+	}
+	s.current = make(map[string]*histogram)
+	s.started = now.Add(-(elapsed % s.window))
+}
+
+// observe record a request, status is 0 when no response was received
+func (s *stats) observe(now time.Time, key string, a Attribution, d time.Duration, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rotate(now)
+	h, ok := s.current[key]
+	if !ok {
+		h = &histogram{buckets: make(map[int]uint64), statuses: make(map[string]uint64), attributions: make(map[Attribution]uint64)}
+		s.current[key] = h
+	}
+	if a != (Attribution{}) {
+		h.attributions[a]++
+	}
+	if status == 0 {
+		h.errors++
+		return
+	}
+	h.statuses[strconv.Itoa(status/100)+"xx"]++
+	h.observe(d)
+}
+
+func (s *stats) snapshot(now time.Time) map[string]LatencyHistogram {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rotate(now)
+	out := make(map[string]LatencyHistogram)
+	counts := make(map[string]map[int]uint64)
+	for _, window := range []map[string]*histogram{s.previous, s.current} {
+		for key, h := range window {
+			if counts[key] == nil {
+				counts[key] = make(map[int]uint64)
+			}
+			lh := out[key]
+			h.addTo(&lh, counts[key])
+			out[key] = lh
+		}
+	}
+
+	for key, lh := range out {
+		indexes := make([]int, 0, len(counts[key]))
+		for i := range counts[key] {
+			indexes = append(indexes, i)
+		}
+		sort.Ints(indexes)
+		for _, i := range indexes {
+			lh.Buckets = append(lh.Buckets, LatencyBucket{UpperBound: bucketBound(i), Count: counts[key][i]})
+		}
+		out[key] = lh
+	}
+	return out
+}
@@ -0,0 +1,76 @@
+package go_http_client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// ErrStrictMode is wrapped by the errors of strict mode checks
+var ErrStrictMode = errors.New("strict mode")
+
+// StrictMode configures the strict HTTP semantics checks, see WithStrictMode
+type StrictMode struct {
+	// AllowBodyOnGet accept request bodies on GET and HEAD requests
+	AllowBodyOnGet bool
+	// AllowMissingContentType accept request bodies without Content-Type
+	AllowMissingContentType bool
+	// AllowUnacceptedResponse accept successful responses whose Content-Type
+	// the request Accept header doesn't cover
+	AllowUnacceptedResponse bool
+}
+
+// WithStrictMode reject obviously wrong usage with errors wrapping
+// ErrStrictMode: bodies on GET/HEAD, bodies without Content-Type, a body
+// option applied over another body and responses not matching the Accept
+// header. Meant to catch sub-client bugs during development
+func WithStrictMode(m StrictMode) Option {
+	return func(c *Client) {
+		c.strict = &m
+	}
+}
+
+type strictKey struct{}
+
+func isStrict(ctx context.Context) bool {
+	return ctx.Value(strictKey{}) != nil
+}
+
+func hasBody(req *http.Request) bool {
+	return req.Body != nil && req.Body != http.NoBody
+}
+
+func (m *StrictMode) checkRequest(req *http.Request) error {
+	if !hasBody(req) {
+		return nil
+	}
+	if !m.AllowBodyOnGet && (req.Method == http.MethodGet || req.Method == http.MethodHead) {
+		return fmt.Errorf("%w: body on %v request to %v", ErrStrictMode, req.Method, req.URL.Path)
+	}
+	if !m.AllowMissingContentType && req.Header.Get("Content-Type") == "" {
+		return fmt.Errorf("%w: body without Content-Type on %v request to %v", ErrStrictMode, req.Method, req.URL.Path)
+	}
+	return nil
+}
+
+func (m *StrictMode) checkResponse(req *http.Request, resp *http.Response) error {
+	accept := req.Header.Get("Accept")
+	if m.AllowUnacceptedResponse || accept == "" || resp.StatusCode == http.StatusNoContent || resp.ContentLength == 0 {
+		return nil
+	}
+
+	mt := mediaType(resp.Header)
+	for _, r := range strings.Split(accept, ",") {
+		pattern, params, err := mime.ParseMediaType(strings.TrimSpace(r))
+		if err != nil || params["q"] == "0" {
+			continue
+		}
+		if mediaTypeMatch(pattern, mt) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: response content type %q doesn't match Accept %q for %v", ErrStrictMode, mt, accept, req.URL.Path)
+}
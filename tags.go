@@ -0,0 +1,54 @@
+package go_http_client
+
+import (
+	"context"
+	"net/http"
+)
+
+// Tags attribute requests to features or tenants, e.g. "job": "sync" or
+// "tenant": "acme". They are added to the debug log fields, are available to
+// hooks with TagsFromContext and are used by the metrics and tracing modules
+type Tags map[string]string
+
+type tagsKey struct{}
+
+// ContextWithTags attach tags to requests made with ctx, on top of the tags
+// already stored in ctx
+func ContextWithTags(ctx context.Context, tags Tags) context.Context {
+	merged := TagsFromContext(ctx)
+	for k, v := range tags {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, tagsKey{}, merged)
+}
+
+// TagsFromContext returns a copy of the tags stored in ctx, never nil
+func TagsFromContext(ctx context.Context) Tags {
+	tags, _ := ctx.Value(tagsKey{}).(Tags)
+	cp := make(Tags, len(tags))
+	for k, v := range tags {
+		cp[k] = v
+	}
+	return cp
+}
+
+// WithTagOpt tag the request, see Tags
+func WithTagOpt(key, value string) RequestOption {
+	return func(req *http.Request) (e error) {
+		*req = *req.WithContext(ContextWithTags(req.Context(), Tags{key: value}))
+		return
+	}
+}
+
+// logFields returns the tags and the request ID of ctx as log fields
+func logFields(ctx context.Context) Fields {
+	tags, _ := ctx.Value(tagsKey{}).(Tags)
+	fields := make(Fields, len(tags)+1)
+	for k, v := range tags {
+		fields[k] = v
+	}
+	if id := RequestIDFromContext(ctx); id != "" {
+		fields["request_id"] = id
+	}
+	return fields
+}
@@ -0,0 +1,108 @@
+package go_http_client
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Timings are the durations of the phases of the last attempt of a request,
+// measured with net/http/httptrace. The dial phases are zero when a pooled
+// connection was reused
+type Timings struct {
+	DNS     time.Duration
+	Connect time.Duration
+	TLS     time.Duration
+	// TTFB is the time from sending the request headers to the first
+	// response byte, the server time plus the round trip
+	TTFB time.Duration
+	// Total is the time from the start of the attempt to the response headers
+	Total  time.Duration
+	Reused bool
+}
+
+// phaseTrace collects the phase timings of an attempt, the httptrace
+// callbacks may run on other goroutines
+type phaseTrace struct {
+	mu                       sync.Mutex
+	start                    time.Time
+	dnsStart, dnsDone        time.Time
+	connectStart, connectEnd time.Time
+	tlsStart, tlsDone        time.Time
+	wroteHeaders, firstByte  time.Time
+	reused                   bool
+}
+
+// withPhaseTrace returns req with a client trace recording its timings
+func withPhaseTrace(req *http.Request) (*http.Request, *phaseTrace) {
+	t := &phaseTrace{start: time.Now()}
+	at := func(dst *time.Time) {
+		t.mu.Lock()
+		*dst = time.Now()
+		t.mu.Unlock()
+	}
+
+	trace := &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { at(&t.dnsStart) },
+		DNSDone:  func(httptrace.DNSDoneInfo) { at(&t.dnsDone) },
+		ConnectStart: func(string, string) {
+			// dialing several addresses, the first attempt starts the phase
+			t.mu.Lock()
+			if t.connectStart.IsZero() {
+				t.connectStart = time.Now()
+			}
+			t.mu.Unlock()
+		},
+		ConnectDone: func(_, _ string, err error) {
+			if err == nil {
+				at(&t.connectEnd)
+			}
+		},
+		TLSHandshakeStart: func() { at(&t.tlsStart) },
+		TLSHandshakeDone:  func(tls.ConnectionState, error) { at(&t.tlsDone) },
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mu.Lock()
+			t.reused = info.Reused
+			t.mu.Unlock()
+		},
+		WroteHeaders:         func() { at(&t.wroteHeaders) },
+		GotFirstResponseByte: func() { at(&t.firstByte) },
+	}
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), trace)), t
+}
+
+func between(start, end time.Time) time.Duration {
+	if start.IsZero() || end.IsZero() {
+		return 0
+	}
+	return end.Sub(start)
+}
+
+// timings returns the timings once the response headers were received
+func (t *phaseTrace) timings() Timings {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return Timings{
+		DNS:     between(t.dnsStart, t.dnsDone),
+		Connect: between(t.connectStart, t.connectEnd),
+		TLS:     between(t.tlsStart, t.tlsDone),
+		TTFB:    between(t.wroteHeaders, t.firstByte),
+		Total:   time.Since(t.start),
+		Reused:  t.reused,
+	}
+}
+
+func logTimings(req *http.Request, t Timings, l Logger) {
+	l.WithFields(logFields(req.Context())).WithFields(Fields{
+		"method":  req.Method,
+		"url":     RedactedURL(req),
+		"dns":     t.DNS,
+		"connect": t.Connect,
+		"tls":     t.TLS,
+		"ttfb":    t.TTFB,
+		"total":   t.Total,
+		"reused":  t.Reused,
+	}).Infof("http request timings")
+}
@@ -0,0 +1,87 @@
+package go_http_client
+
+import (
+	"context"
+	"net/http"
+)
+
+// TraceHeaders are the trace context headers kept by ContextWithTraceHeaders:
+// W3C trace context and the single and multi header B3 formats
+var TraceHeaders = []string{
+	"Traceparent", "Tracestate",
+	"B3", "X-B3-Traceid", "X-B3-Spanid", "X-B3-Parentspanid", "X-B3-Sampled", "X-B3-Flags",
+}
+
+type traceHeadersKey struct{}
+
+// ContextWithTraceHeaders attach the trace context headers found in h, e.g.
+// the headers of the incoming request being served, to requests made with ctx
+func ContextWithTraceHeaders(ctx context.Context, h http.Header) context.Context {
+	trace := make(http.Header)
+	for _, name := range TraceHeaders {
+		if vs := h.Values(name); len(vs) > 0 {
+			trace[name] = append([]string(nil), vs...)
+		}
+	}
+	return context.WithValue(ctx, traceHeadersKey{}, trace)
+}
+
+// TraceHeadersFromContext returns the trace context headers stored in ctx, if
+// any
+func TraceHeadersFromContext(ctx context.Context) http.Header {
+	h, _ := ctx.Value(traceHeadersKey{}).(http.Header)
+	return h.Clone()
+}
+
+// WithTracePropagation send the trace context headers stored in the request
+// context with ContextWithTraceHeaders, for services which propagate trace
+// IDs without a tracing library. Headers set by request options are kept
+func WithTracePropagation() Option {
+	return func(c *Client) {
+		c.propagateTrace = true
+	}
+}
+
+func (c *Client) setTraceHeaders(req *http.Request) {
+	if !c.propagateTrace {
+		return
+	}
+	h, _ := req.Context().Value(traceHeadersKey{}).(http.Header)
+	for name, vs := range h {
+		if req.Header.Get(name) == "" {
+			req.Header[name] = append([]string(nil), vs...)
+		}
+	}
+}
+
+// SpanStarter start a tracing span for req once it is built, with the request
+// options, tags and attribution applied, right before it is sent. It may
+// replace the request context and set headers to propagate the span. The
+// returned function ends the span, it is called exactly once when the
+// response headers were received or the request failed, with the response
+// metadata, nil when no response was received, and the error
+type SpanStarter func(req *http.Request) (end func(resp *Response, err error))
+
+// WithSpanStarter trace the requests of the client with s, for tracing
+// integrations such as the otel and datadog modules. Requests refused before
+// being sent, e.g. by a failing option or after Shutdown, and the requests
+// of PrepareRequest aren't traced
+func WithSpanStarter(s SpanStarter) Option {
+	return func(c *Client) {
+		c.spanStarters = append(c.spanStarters, s)
+	}
+}
+
+// startSpans start the spans of req, the returned function ends them in the
+// reverse order
+func (c *Client) startSpans(req *http.Request) func(resp *Response, err error) {
+	ends := make([]func(*Response, error), 0, len(c.spanStarters))
+	for _, start := range c.spanStarters {
+		ends = append(ends, start(req))
+	}
+	return func(resp *Response, err error) {
+		for i := len(ends) - 1; i >= 0; i-- {
+			ends[i](resp, err)
+		}
+	}
+}
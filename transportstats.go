@@ -0,0 +1,66 @@
+package go_http_client
+
+import (
+	"net/http/httptrace"
+)
+
+// TransportStats are the connection counters of the client and its clones,
+// observed with net/http/httptrace since the transport doesn't expose its pool
+type TransportStats struct {
+	// Active is the number of connections held by requests in flight, a
+	// request holds its connection until its response body is closed
+	Active int
+	// Idle estimates the connections parked in the idle pool. Idle
+	// connections closed by the transport, e.g. after IdleConnTimeout, and
+	// HTTP/2 connections aren't seen, so it is an upper bound
+	Idle int
+	// Opened is the number of new connections
+	Opened uint64
+	// Reused is the number of requests sent on a pooled connection
+	Reused uint64
+}
+
+// ReuseRatio returns the fraction of the requests sent on a pooled
+// connection. A low ratio under load hints at a pool which is too small,
+// see http.Transport.MaxIdleConnsPerHost
+func (s TransportStats) ReuseRatio() float64 {
+	if s.Opened+s.Reused == 0 {
+		return 0
+	}
+	return float64(s.Reused) / float64(s.Opened+s.Reused)
+}
+
+// TransportStats returns the connection counters of the client
+func (c *Client) TransportStats() TransportStats {
+	return c.journal.transportStats()
+}
+
+// gotConn account for the connection of an attempt, the journal lock is held
+func (j *journal) gotConn(e *journalEntry, info httptrace.GotConnInfo) {
+	if info.Reused {
+		j.conns.Reused++
+	} else {
+		j.conns.Opened++
+	}
+	if info.WasIdle && j.conns.Idle > 0 {
+		j.conns.Idle--
+	}
+	if !e.hasConn {
+		e.hasConn = true
+		j.conns.Active++
+	}
+}
+
+func (j *journal) putIdleConn(err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if err == nil {
+		j.conns.Idle++
+	}
+}
+
+func (j *journal) transportStats() TransportStats {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.conns
+}
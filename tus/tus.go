@@ -0,0 +1,273 @@
+// Package tus implements the client side of the tus resumable upload
+// protocol (https://tus.io, core protocol 1.0.0 with the creation extension)
+// on top of go-http-client, so interrupted uploads continue from the offset
+// the server already has.
+package tus
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	cl "github.com/Traumeel/go-http-client"
+)
+
+const (
+	// Version is the protocol version sent in Tus-Resumable
+	Version = "1.0.0"
+	// OffsetContentType is the content type of PATCH request bodies
+	OffsetContentType = "application/offset+octet-stream"
+	// DefaultChunkSize is the default PATCH request body size
+	DefaultChunkSize = 4 << 20
+)
+
+// ErrOffsetMismatch is returned when the server offset doesn't match the
+// uploaded data, e.g. when two clients upload to the same resource
+var ErrOffsetMismatch = errors.New("tus upload offset mismatch")
+
+// Store remembers upload URLs by a fingerprint of the source, so uploads can
+// be resumed by another process
+type Store interface {
+	Get(fingerprint string) (string, bool)
+	Set(fingerprint, uploadURL string)
+	Delete(fingerprint string)
+}
+
+// MemoryStore is a Store for the lifetime of the process
+type MemoryStore struct {
+	mu   sync.Mutex
+	urls map[string]string
+}
+
+func (s *MemoryStore) Get(fingerprint string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.urls[fingerprint]
+	return u, ok
+}
+
+func (s *MemoryStore) Set(fingerprint, uploadURL string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.urls == nil {
+		s.urls = make(map[string]string)
+	}
+	s.urls[fingerprint] = uploadURL
+}
+
+func (s *MemoryStore) Delete(fingerprint string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.urls, fingerprint)
+}
+
+type Option func(*Uploader)
+
+// WithChunkSize set the size of the PATCH request bodies
+func WithChunkSize(n int64) Option {
+	return func(u *Uploader) {
+		u.chunkSize = n
+	}
+}
+
+// WithStore remember upload URLs in s, see Uploader.Upload
+func WithStore(s Store) Option {
+	return func(u *Uploader) {
+		u.store = s
+	}
+}
+
+// WithProgress call fn after every chunk with the confirmed offset
+func WithProgress(fn func(offset, size int64)) Option {
+	return func(u *Uploader) {
+		u.progress = fn
+	}
+}
+
+// Uploader performs tus uploads through a client
+type Uploader struct {
+	client    *cl.Client
+	chunkSize int64
+	store     Store
+	progress  func(offset, size int64)
+}
+
+func NewUploader(client *cl.Client, options ...Option) *Uploader {
+	u := &Uploader{
+		client:    client,
+		chunkSize: DefaultChunkSize,
+		store:     &MemoryStore{},
+	}
+
+	for _, opt := range options {
+		opt(u)
+	}
+
+	return u
+}
+
+func tusHeaders(extra http.Header) cl.RequestOption {
+	h := http.Header{"Tus-Resumable": {Version}}
+	for k, vs := range extra {
+		h[k] = vs
+	}
+	return cl.WithHeadersOpt(h)
+}
+
+func captureResponse(dst *http.Response) cl.ResponseParser {
+	return func(resp *http.Response) (e error) {
+		*dst = *resp
+		return
+	}
+}
+
+// Create create an upload resource of size bytes on the collection at path
+// and returns its absolute URL
+func (u *Uploader) Create(ctx context.Context, path string, size int64, metadata map[string]string) (string, error) {
+	h := http.Header{"Upload-Length": {strconv.FormatInt(size, 10)}}
+	if len(metadata) > 0 {
+		h.Set("Upload-Metadata", encodeMetadata(metadata))
+	}
+
+	resp := http.Response{}
+	if err := u.client.DoRequest(ctx, http.MethodPost, path, captureResponse(&resp), tusHeaders(h)); err != nil {
+		return "", fmt.Errorf("failed to create tus upload: %w", err)
+	}
+
+	location, err := resp.Location()
+	if err != nil {
+		return "", fmt.Errorf("failed to create tus upload: %w", err)
+	}
+	return location.String(), nil
+}
+
+// Offset returns how many bytes of the upload the server has
+func (u *Uploader) Offset(ctx context.Context, uploadURL string) (int64, error) {
+	resp := http.Response{}
+	if err := u.client.DoRequestURL(ctx, http.MethodHead, uploadURL, captureResponse(&resp), tusHeaders(nil)); err != nil {
+		return 0, err
+	}
+	return parseOffset(resp.Header)
+}
+
+// Resume send src from the server offset until size bytes were uploaded
+func (u *Uploader) Resume(ctx context.Context, uploadURL string, src io.ReadSeeker, size int64) error {
+	offset, err := u.Offset(ctx, uploadURL)
+	if err != nil {
+		return err
+	}
+	return u.upload(ctx, uploadURL, src, offset, size)
+}
+
+// Upload create or resume the upload of src. Uploads are identified by
+// fingerprint in the store, a known upload which the server no longer has
+// is created again
+func (u *Uploader) Upload(ctx context.Context, path, fingerprint string, src io.ReadSeeker, size int64, metadata map[string]string) (string, error) {
+	if uploadURL, ok := u.store.Get(fingerprint); ok {
+		offset, err := u.Offset(ctx, uploadURL)
+		if err == nil {
+			return uploadURL, u.finish(ctx, fingerprint, uploadURL, src, offset, size)
+		}
+		if !isGone(err) {
+			return uploadURL, err
+		}
+		u.store.Delete(fingerprint)
+	}
+
+	uploadURL, err := u.Create(ctx, path, size, metadata)
+	if err != nil {
+		return "", err
+	}
+	u.store.Set(fingerprint, uploadURL)
+	return uploadURL, u.finish(ctx, fingerprint, uploadURL, src, 0, size)
+}
+
+func (u *Uploader) finish(ctx context.Context, fingerprint, uploadURL string, src io.ReadSeeker, offset, size int64) error {
+	if err := u.upload(ctx, uploadURL, src, offset, size); err != nil {
+		return err
+	}
+	u.store.Delete(fingerprint)
+	return nil
+}
+
+func (u *Uploader) upload(ctx context.Context, uploadURL string, src io.ReadSeeker, offset, size int64) error {
+	for offset < size {
+		if _, err := src.Seek(offset, io.SeekStart); err != nil {
+			return err
+		}
+
+		n := u.chunkSize
+		if size-offset < n {
+			n = size - offset
+		}
+
+		h := http.Header{
+			"Upload-Offset": {strconv.FormatInt(offset, 10)},
+			"Content-Type":  {OffsetContentType},
+		}
+		resp := http.Response{}
+		err := u.client.DoRequestURL(ctx, http.MethodPatch, uploadURL, captureResponse(&resp),
+			tusHeaders(h), cl.WithBodyOpt(io.LimitReader(src, n)), withContentLength(n))
+		if err != nil {
+			return fmt.Errorf("failed to upload chunk at offset %v: %w", offset, err)
+		}
+
+		next, err := parseOffset(resp.Header)
+		if err != nil {
+			return err
+		}
+		if next <= offset || next > size {
+			return fmt.Errorf("%w: sent %v bytes at %v, server is at %v", ErrOffsetMismatch, n, offset, next)
+		}
+		offset = next
+
+		if u.progress != nil {
+			u.progress(offset, size)
+		}
+	}
+	return nil
+}
+
+func withContentLength(n int64) cl.RequestOption {
+	return func(req *http.Request) (e error) {
+		req.ContentLength = n
+		return
+	}
+}
+
+func parseOffset(h http.Header) (int64, error) {
+	offset, err := strconv.ParseInt(h.Get("Upload-Offset"), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid Upload-Offset %q: %w", h.Get("Upload-Offset"), err)
+	}
+	return offset, nil
+}
+
+func isGone(err error) bool {
+	var sc cl.StatusCodeError
+	if !errors.As(err, &sc) {
+		return false
+	}
+	return sc.Code == http.StatusNotFound || sc.Code == http.StatusGone || sc.Code == http.StatusForbidden
+}
+
+func encodeMetadata(metadata map[string]string) string {
+	keys := make([]string, 0, len(metadata))
+	for k := range metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, k+" "+base64.StdEncoding.EncodeToString([]byte(metadata[k])))
+	}
+	return strings.Join(pairs, ",")
+}
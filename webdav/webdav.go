@@ -0,0 +1,233 @@
+// Package webdav adds the WebDAV methods (RFC 4918) and a multistatus parser
+// on top of go-http-client, reusing its endpoint and auth options.
+package webdav
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	cl "github.com/Traumeel/go-http-client"
+)
+
+const (
+	MethodPropfind = "PROPFIND"
+	MethodMkcol    = "MKCOL"
+	MethodMove     = "MOVE"
+	MethodCopy     = "COPY"
+)
+
+// Depth values of the Depth header
+const (
+	DepthZero     = "0"
+	DepthOne      = "1"
+	DepthInfinity = "infinity"
+)
+
+// StatusMultiStatus is the status code of multistatus responses
+const StatusMultiStatus = http.StatusMultiStatus
+
+// Multistatus is a 207 Multi-Status response body
+type Multistatus struct {
+	XMLName   xml.Name   `xml:"DAV: multistatus"`
+	Responses []Response `xml:"response"`
+}
+
+// Response is the status of a single resource in a multistatus body
+type Response struct {
+	Href     []string   `xml:"href"`
+	Propstat []Propstat `xml:"propstat"`
+	Status   string     `xml:"status,omitempty"`
+}
+
+// Propstat groups the properties sharing a status
+type Propstat struct {
+	Prop   Prop   `xml:"prop"`
+	Status string `xml:"status"`
+}
+
+// Prop holds the live properties most clients need, everything else is
+// available in Raw
+type Prop struct {
+	DisplayName   string        `xml:"displayname,omitempty"`
+	ContentLength string        `xml:"getcontentlength,omitempty"`
+	ContentType   string        `xml:"getcontenttype,omitempty"`
+	ETag          string        `xml:"getetag,omitempty"`
+	LastModified  string        `xml:"getlastmodified,omitempty"`
+	ResourceType  *ResourceType `xml:"resourcetype,omitempty"`
+	Raw           []RawProp     `xml:",any"`
+}
+
+// ResourceType tells collections apart from plain resources
+type ResourceType struct {
+	Collection *struct{} `xml:"collection"`
+}
+
+// RawProp is any property not mapped in Prop
+type RawProp struct {
+	XMLName xml.Name
+	Inner   string `xml:",innerxml"`
+}
+
+// StatusCode returns the code of an HTTP status line such as
+// "HTTP/1.1 200 OK", 0 when the line can't be parsed
+func StatusCode(status string) int {
+	parts := strings.Fields(status)
+	if len(parts) < 2 {
+		return 0
+	}
+	code, _ := strconv.Atoi(parts[1])
+	return code
+}
+
+// IsCollection reports whether the resource is a collection
+func (p Prop) IsCollection() bool {
+	return p.ResourceType != nil && p.ResourceType.Collection != nil
+}
+
+// Size returns getcontentlength as a number, -1 when missing
+func (p Prop) Size() int64 {
+	n, err := strconv.ParseInt(p.ContentLength, 10, 64)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// Modified returns getlastmodified as a time
+func (p Prop) Modified() (time.Time, error) {
+	return http.ParseTime(p.LastModified)
+}
+
+// Props returns the properties of the response with a 2xx propstat status
+func (r Response) Props() Prop {
+	for _, ps := range r.Propstat {
+		if code := StatusCode(ps.Status); code >= 200 && code < 300 {
+			return ps.Prop
+		}
+	}
+	return Prop{}
+}
+
+// MultistatusParser decode a multistatus XML body into dst
+func MultistatusParser(dst *Multistatus) cl.ResponseParser {
+	return func(resp *http.Response) (e error) {
+		if resp == nil || dst == nil {
+			return fmt.Errorf("MultistatusParser function error: %v | %v", resp, dst)
+		}
+		return xml.NewDecoder(resp.Body).Decode(dst)
+	}
+}
+
+// WithDepthOpt set the Depth header
+func WithDepthOpt(depth string) cl.RequestOption {
+	return func(req *http.Request) (e error) {
+		req.Header.Set("Depth", depth)
+		return
+	}
+}
+
+// WithDestinationOpt set the Destination header to path resolved against the
+// request URL, so an absolute path keeps the scheme and host of the client
+func WithDestinationOpt(path string, overwrite bool) cl.RequestOption {
+	return func(req *http.Request) (e error) {
+		ref, err := url.Parse(path)
+		if err != nil {
+			return fmt.Errorf("WithDestinationOpt error: %w", err)
+		}
+		req.Header.Set("Destination", req.URL.ResolveReference(ref).String())
+		if overwrite {
+			req.Header.Set("Overwrite", "T")
+		} else {
+			req.Header.Set("Overwrite", "F")
+		}
+		return
+	}
+}
+
+// Client performs WebDAV requests, passing the paths to the underlying client
+type Client struct {
+	*cl.Client
+}
+
+func NewClient(c *cl.Client) *Client {
+	return &Client{c}
+}
+
+// PropFind fetch the given properties, or all of them when props is empty,
+// of path and its members down to depth
+func (c *Client) PropFind(ctx context.Context, path, depth string, props []xml.Name, options ...cl.RequestOption) (*Multistatus, error) {
+	body, err := propfindBody(props)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := append([]cl.RequestOption{
+		cl.WithBodyOpt(bytes.NewReader(body)),
+		cl.WithHeadersOpt(http.Header{"Content-Type": {`application/xml; charset="utf-8"`}}),
+		WithDepthOpt(depth),
+	}, options...)
+
+	ms := &Multistatus{}
+	if err := c.DoRequest(ctx, MethodPropfind, path, MultistatusParser(ms), opts...); err != nil {
+		return nil, err
+	}
+	return ms, nil
+}
+
+// Mkcol create the collection at path
+func (c *Client) Mkcol(ctx context.Context, path string, options ...cl.RequestOption) error {
+	return c.DoRequestNoBody(ctx, MethodMkcol, path, options...)
+}
+
+// Move move the resource at path to destination
+func (c *Client) Move(ctx context.Context, path, destination string, overwrite bool, options ...cl.RequestOption) error {
+	opts := append([]cl.RequestOption{WithDestinationOpt(destination, overwrite)}, options...)
+	return c.DoRequestNoBody(ctx, MethodMove, path, opts...)
+}
+
+// Copy copy the resource at path to destination, collections are copied
+// down to depth (DepthZero or DepthInfinity)
+func (c *Client) Copy(ctx context.Context, path, destination, depth string, overwrite bool, options ...cl.RequestOption) error {
+	opts := append([]cl.RequestOption{WithDestinationOpt(destination, overwrite), WithDepthOpt(depth)}, options...)
+	return c.DoRequestNoBody(ctx, MethodCopy, path, opts...)
+}
+
+type propfind struct {
+	XMLName xml.Name   `xml:"D:propfind"`
+	DAV     string     `xml:"xmlns:D,attr"`
+	AllProp *struct{}  `xml:"D:allprop,omitempty"`
+	Prop    *propNames `xml:"D:prop,omitempty"`
+}
+
+type propNames struct {
+	Names []propName
+}
+
+type propName struct {
+	XMLName xml.Name
+}
+
+func propfindBody(props []xml.Name) ([]byte, error) {
+	pf := propfind{DAV: "DAV:"}
+	if len(props) == 0 {
+		pf.AllProp = &struct{}{}
+	} else {
+		pf.Prop = &propNames{}
+		for _, name := range props {
+			pf.Prop.Names = append(pf.Prop.Names, propName{XMLName: name})
+		}
+	}
+
+	body, err := xml.Marshal(pf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal propfind body: %w", err)
+	}
+	return append([]byte(xml.Header), body...), nil
+}
@@ -0,0 +1,8 @@
+module github.com/Traumeel/go-http-client/websocket
+
+go 1.18
+
+require (
+	github.com/Traumeel/go-http-client v0.1.0
+	github.com/gorilla/websocket v1.5.3
+)
@@ -0,0 +1,63 @@
+// Package websocket opens WebSocket connections with the endpoint, request
+// options and transport settings of a client, so realtime code shares the
+// auth stack of the REST calls. It lives in its own module so the WebSocket
+// library is only pulled in by the users who need it
+package websocket
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	cl "github.com/Traumeel/go-http-client"
+	gorilla "github.com/gorilla/websocket"
+)
+
+// handshakeHeaders are set by the dialer itself and can't be passed along
+var handshakeHeaders = []string{
+	"Upgrade",
+	"Connection",
+	"Sec-Websocket-Key",
+	"Sec-Websocket-Version",
+	"Sec-Websocket-Extensions",
+}
+
+// Dial open a WebSocket connection to path on the client endpoint, http and
+// https endpoints become ws and wss. The handshake carries the headers and
+// cookies the client would send, including credentials set by global request
+// options, and goes through the TLS config and proxy of the client transport
+// when it is an *http.Transport. The response of a failed handshake is
+// returned along with the error
+func Dial(ctx context.Context, c *cl.Client, path string, options ...cl.RequestOption) (*gorilla.Conn, *http.Response, error) {
+	req, err := c.PrepareRequest(ctx, http.MethodGet, path, options...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	u := *req.URL
+	switch strings.ToLower(u.Scheme) {
+	case "http":
+		u.Scheme = "ws"
+	case "https":
+		u.Scheme = "wss"
+	}
+
+	header := req.Header.Clone()
+	for _, h := range handshakeHeaders {
+		header.Del(h)
+	}
+	if req.Host != "" && req.Host != req.URL.Host {
+		header.Set("Host", req.Host)
+	}
+
+	dialer := *gorilla.DefaultDialer
+	if t, ok := c.Transport().(*http.Transport); ok {
+		dialer.Proxy = t.Proxy
+		dialer.NetDialContext = t.DialContext
+		if t.TLSClientConfig != nil {
+			dialer.TLSClientConfig = t.TLSClientConfig.Clone()
+		}
+	}
+
+	return dialer.DialContext(ctx, u.String(), header)
+}
@@ -0,0 +1,65 @@
+package go_http_client
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+)
+
+// WithXmlBodyOpt marshal v as the XML request body and set the Content-Type
+func WithXmlBodyOpt(v interface{}) RequestOption {
+	return func(req *http.Request) (e error) {
+		if req == nil {
+			return fmt.Errorf("WithXmlBodyOpt error: %v | %v", req, v)
+		}
+		data, err := xml.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request body: %w", err)
+		}
+		if err := WithBodyOpt(bytes.NewReader(data))(req); err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", MediaTypeXML)
+		return
+	}
+}
+
+// XmlParser decode the XML response into dst, see WithDefaultAcceptOpt to
+// accept application/xml
+func XmlParser(dst interface{}) ResponseParser {
+	return func(resp *http.Response) (e error) {
+		if resp == nil || dst == nil {
+			return fmt.Errorf("XmlParser function error: %v | %v", resp, dst)
+		}
+		if err := xml.NewDecoder(resp.Body).Decode(dst); err != nil {
+			return err
+		}
+		setResponseMetadata(dst, resp)
+		return
+	}
+}
+
+func (c *Client) GetXml(ctx context.Context, path string, intf interface{}, options ...RequestOption) error {
+	return c.DoRequestXml(ctx, http.MethodGet, path, intf, options...)
+}
+
+// DoRequestXml decode the XML response into intf. The Accept header defaults
+// to application/xml
+func (c *Client) DoRequestXml(ctx context.Context, method, path string, intf interface{}, options ...RequestOption) error {
+	opts := append(options[:len(options):len(options)], WithDefaultAcceptOpt(MediaTypeXML))
+	return c.DoRequest(ctx, method, path, XmlParser(intf), opts...)
+}
+
+// PostXml send in as an XML body and decode the XML response into out
+func (c *Client) PostXml(ctx context.Context, path string, in, out interface{}, options ...RequestOption) error {
+	return c.DoRequestXmlBody(ctx, http.MethodPost, path, in, out, options...)
+}
+
+// DoRequestXmlBody send in as an XML body and decode the XML response into
+// out. The Accept header defaults to application/xml
+func (c *Client) DoRequestXmlBody(ctx context.Context, method, path string, in, out interface{}, options ...RequestOption) error {
+	opts := append([]RequestOption{WithXmlBodyOpt(in)}, options...)
+	return c.DoRequestXml(ctx, method, path, out, opts...)
+}
@@ -0,0 +1,8 @@
+module github.com/Traumeel/go-http-client/yaml
+
+go 1.18
+
+require (
+	github.com/Traumeel/go-http-client v0.1.0
+	gopkg.in/yaml.v3 v3.0.1
+)
@@ -0,0 +1,54 @@
+// Package yaml adds YAML request bodies and response parsers to the client.
+// It lives in its own module so the YAML library is only pulled in by the
+// users who need it
+package yaml
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	cl "github.com/Traumeel/go-http-client"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// ContentType is the media type of YAML documents
+const ContentType = "application/yaml"
+
+func decode(r io.Reader, v interface{}) error {
+	return yamlv3.NewDecoder(r).Decode(v)
+}
+
+// Parser decode the first YAML document of the response into dst
+func Parser(dst interface{}) cl.ResponseParser {
+	return cl.DecodingParser(dst, decode)
+}
+
+// DocumentsParser decode every document of a multi-document YAML response,
+// separated by "---", appending them to dst
+func DocumentsParser[T any](dst *[]T) cl.ResponseParser {
+	return func(resp *http.Response) (e error) {
+		if resp == nil || dst == nil {
+			return fmt.Errorf("yaml.DocumentsParser function error: %v | %v", resp, dst)
+		}
+
+		dec := yamlv3.NewDecoder(resp.Body)
+		for {
+			var doc T
+			err := dec.Decode(&doc)
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("failed to decode yaml document %v: %w", len(*dst), err)
+			}
+			*dst = append(*dst, doc)
+		}
+	}
+}
+
+// WithBodyOpt marshal v as the YAML request body and set the Content-Type
+func WithBodyOpt(v interface{}) cl.RequestOption {
+	return cl.WithEncodedBodyOpt(v, ContentType, yamlv3.Marshal)
+}
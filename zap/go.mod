@@ -0,0 +1,10 @@
+module github.com/Traumeel/go-http-client/zap
+
+go 1.18
+
+require (
+	github.com/Traumeel/go-http-client v0.1.0
+	go.uber.org/zap v1.27.0
+)
+
+require go.uber.org/multierr v1.10.0 // indirect
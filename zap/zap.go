@@ -0,0 +1,64 @@
+// Package zap adapts a zap logger to the client Logger interface. It lives in
+// its own module so zap is only pulled in by the users who need it
+package zap
+
+import (
+	"fmt"
+	"sort"
+
+	cl "github.com/Traumeel/go-http-client"
+	zaplib "go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type logger struct {
+	l *zaplib.Logger
+}
+
+// New returns a Logger writing to l
+func New(l *zaplib.Logger) cl.Logger {
+	// report the caller of the client rather than the adapter
+	return logger{l: l.WithOptions(zaplib.AddCallerSkip(2))}
+}
+
+// WithLog log with the zap logger l
+func WithLog(l *zaplib.Logger) cl.Option {
+	return cl.WithLog(New(l))
+}
+
+func (t logger) WithFields(fields cl.Fields) cl.Logger {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	zf := make([]zaplib.Field, 0, len(fields))
+	for _, k := range keys {
+		zf = append(zf, zaplib.Any(k, fields[k]))
+	}
+	return logger{l: t.l.With(zf...)}
+}
+
+func (t logger) log(level zapcore.Level, format string, args []interface{}) {
+	if ce := t.l.Check(level, ""); ce != nil {
+		ce.Message = fmt.Sprintf(format, args...)
+		ce.Write()
+	}
+}
+
+func (t logger) Debugf(format string, args ...interface{}) {
+	t.log(zapcore.DebugLevel, format, args)
+}
+
+func (t logger) Infof(format string, args ...interface{}) {
+	t.log(zapcore.InfoLevel, format, args)
+}
+
+func (t logger) Warnf(format string, args ...interface{}) {
+	t.log(zapcore.WarnLevel, format, args)
+}
+
+func (t logger) Errorf(format string, args ...interface{}) {
+	t.log(zapcore.ErrorLevel, format, args)
+}
@@ -0,0 +1,43 @@
+// Package zerolog adapts a zerolog logger to the client Logger interface. It
+// lives in its own module so zerolog is only pulled in by the users who need
+// it
+package zerolog
+
+import (
+	cl "github.com/Traumeel/go-http-client"
+	zerologlib "github.com/rs/zerolog"
+)
+
+type logger struct {
+	l zerologlib.Logger
+}
+
+// New returns a Logger writing to l
+func New(l zerologlib.Logger) cl.Logger {
+	return logger{l: l}
+}
+
+// WithLog log with the zerolog logger l
+func WithLog(l zerologlib.Logger) cl.Option {
+	return cl.WithLog(New(l))
+}
+
+func (t logger) WithFields(fields cl.Fields) cl.Logger {
+	return logger{l: t.l.With().Fields(map[string]interface{}(fields)).Logger()}
+}
+
+func (t logger) Debugf(format string, args ...interface{}) {
+	t.l.Debug().Msgf(format, args...)
+}
+
+func (t logger) Infof(format string, args ...interface{}) {
+	t.l.Info().Msgf(format, args...)
+}
+
+func (t logger) Warnf(format string, args ...interface{}) {
+	t.l.Warn().Msgf(format, args...)
+}
+
+func (t logger) Errorf(format string, args ...interface{}) {
+	t.l.Error().Msgf(format, args...)
+}